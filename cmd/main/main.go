@@ -2,13 +2,17 @@ package main
 
 import (
 	"log"
+	"os"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database" // Ganti dengan path modul Anda
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"    
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/handler"    
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/middleware"    
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
 )
 
 func main() {
@@ -17,11 +21,49 @@ func main() {
 	// Menghubungkan ke database
 	database.ConnectDatabase()
 
+	// Transaksi yang dibuat sebelum kolom status ada sudah diposting, jadi diisi cleared
+	// (bukan default pending) agar cleared balance akun lama tetap benar
+	backfillStatus := database.DB.Migrator().HasTable(&model.Transaction{}) && !database.DB.Migrator().HasColumn(&model.Transaction{}, "status")
+
 	// Menjalankan Auto Migration
-	err := database.DB.AutoMigrate(&model.User{}, &model.Account{}, &model.Category{}, &model.SubCategory{}, &model.Transaction{}, &model.Budget{})
+	err := database.DB.AutoMigrate(&model.User{}, &model.Account{}, &model.Category{}, &model.SubCategory{}, &model.Transaction{}, &model.Budget{}, &model.BudgetTemplate{}, &model.BudgetTemplateItem{}, &model.Reconciliation{}, &model.Payee{}, &model.PayeeAlias{}, &model.SavingsGoal{}, &model.SavingsGoalContribution{}, &model.LoanDetail{}, &model.CreditCardDetail{}, &model.Bill{}, &model.BillPayment{}, &model.BillReminder{}, &model.DetectedSubscription{}, &model.AnomalyAlert{}, &model.Notification{}, &model.NotificationPreference{}, &model.BudgetAlert{}, &model.WebhookEndpoint{}, &model.WebhookDelivery{})
 	if err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
+	if backfillStatus {
+		if err := database.DB.Model(&model.Transaction{}).Where("1 = 1").Update("status", model.TransactionStatusCleared).Error; err != nil {
+			log.Fatal("Failed to backfill transaction status:", err)
+		}
+	}
+	// Akun lama belum punya transaksi saldo awal, sehingga saldonya tidak bisa dihitung ulang dari
+	// ledger. Dicatat sekali per akun sebagai opening_balance sebelum transaksi pertamanya.
+	if recorded, err := ledger.BackfillOpeningBalances(database.DB); err != nil {
+		log.Fatal("Failed to backfill opening balances:", err)
+	} else if recorded > 0 {
+		log.Printf("Backfilled opening balances for %d accounts", recorded)
+	}
+	// Index budget lama tidak memuat kolom week, sehingga bentrok dengan budget mingguan
+	if database.DB.Migrator().HasIndex(&model.Budget{}, "idx_user_category_month_year") {
+		if err := database.DB.Migrator().DropIndex(&model.Budget{}, "idx_user_category_month_year"); err != nil {
+			log.Fatal("Failed to drop old budget index:", err)
+		}
+	}
+
+	// Channel pemberitahuan: kotak masuk dan webhook milik user selalu aktif, email jika
+	// SMTP_HOST diisi. User bisa mematikan per jenis & channel.
+	notify.Default.Register(notify.InboxChannel{DB: database.DB})
+	notify.Default.Register(handler.WebhookNotificationChannel{})
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		notify.Default.Register(notify.NewSMTPChannel(database.DB, host, os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM")))
+	}
+	notify.Default.SetPreferences(notify.DBPreferences{DB: database.DB})
+
+	// Menjalankan pengingat tagihan di background
+	handler.StartBillReminders(time.Hour)
+	// Mendeteksi langganan dari riwayat transaksi setiap hari
+	handler.StartSubscriptionDetection(24 * time.Hour)
+	// Memeriksa pengeluaran tidak biasa secara berkala
+	handler.StartAnomalyAlerts(6 * time.Hour)
 
 	// Inisialisasi Gin Router
 	router := gin.Default()
@@ -31,7 +73,7 @@ func main() {
 	config := cors.DefaultConfig()
 	config.AllowOrigins = []string{"http://localhost:3000", "http://10.74.197.27:3000"} // <-- Tambahkan alamat IP frontend Anda
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "Last-Event-ID"}
 	router.Use(cors.New(config))
 
 	authRoutes := router.Group("/auth")
@@ -41,28 +83,101 @@ func main() {
 	}
 
 
+	// Stream di luar grup /api agar bisa diautentikasi dengan tiket sekali pakai (?ticket=)
+	router.GET("/api/events/stream", middleware.StreamTicketMiddleware(), handler.StreamEvents)
+
 	apiRoutes := router.Group("/api")
 	apiRoutes.Use(middleware.AuthMiddleware()) 
 	{
 		apiRoutes.GET("/profile", handler.GetCurrentUserProfile)
+		apiRoutes.GET("/settings/budget-period", handler.GetBudgetPeriodSettings)
+		apiRoutes.PUT("/settings/budget-period", handler.UpdateBudgetPeriodSettings)
 
 		//account routes
 		apiRoutes.POST("/accounts", handler.CreateAccount)
         apiRoutes.GET("/accounts", handler.GetAccounts)
+        apiRoutes.POST("/accounts/recompute", handler.RecomputeAccountBalances)
         apiRoutes.PUT("/accounts/:id", handler.UpdateAccount)
         apiRoutes.DELETE("/accounts/:id", handler.DeleteAccount)
+        apiRoutes.POST("/accounts/:id/archive", handler.ArchiveAccount)
+        apiRoutes.POST("/accounts/:id/unarchive", handler.UnarchiveAccount)
+        apiRoutes.GET("/accounts/:id/ledger", handler.GetAccountLedger)
+        apiRoutes.GET("/accounts/:id/balance", handler.GetAccountBalanceAt)
+
+		// Rute Pinjaman & Utang
+		apiRoutes.PUT("/accounts/:id/loan", handler.SetLoanDetail)
+		apiRoutes.GET("/accounts/:id/loan", handler.GetLoanDetail)
+		apiRoutes.GET("/accounts/:id/loan/schedule", handler.GetLoanSchedule)
+		apiRoutes.GET("/debts/payoff", handler.GetDebtPayoffSimulation)
+
+		// Rute Kartu Kredit
+		apiRoutes.PUT("/accounts/:id/credit-card", handler.SetCreditCardDetail)
+		apiRoutes.GET("/accounts/:id/credit-card/statement", handler.GetCreditCardStatement)
+		apiRoutes.POST("/accounts/:id/credit-card/pay", handler.PayCreditCard)
+
+		// Rute Tagihan
+		apiRoutes.POST("/bills", handler.CreateBill)
+		apiRoutes.GET("/bills", handler.GetBills)
+		apiRoutes.GET("/bills/calendar", handler.GetBillCalendar)
+		apiRoutes.PUT("/bills/:id", handler.UpdateBill)
+		apiRoutes.DELETE("/bills/:id", handler.DeleteBill)
+		apiRoutes.POST("/bills/:id/pay", handler.PayBill)
+		apiRoutes.DELETE("/bills/:id/payments/:payment_id", handler.DeleteBillPayment)
+
+		// Rute Langganan
+		apiRoutes.GET("/subscriptions", handler.GetSubscriptions)
+		apiRoutes.POST("/subscriptions/detect", handler.RunSubscriptionDetection)
+		apiRoutes.POST("/subscriptions/:id/confirm", handler.ConfirmSubscription)
+		apiRoutes.POST("/subscriptions/:id/dismiss", handler.DismissSubscription)
+
+		// Rute Net Worth
+		apiRoutes.GET("/net-worth", handler.GetNetWorth)
+		apiRoutes.GET("/net-worth/history", handler.GetNetWorthHistory)
+
+		// Rute Proyeksi Arus Kas
+		apiRoutes.GET("/forecast", handler.GetCashFlowForecast)
+
+		// Rute Insight
+		apiRoutes.GET("/insights/anomalies", handler.GetAnomalyInsights)
+
+		// Rute Pemberitahuan
+		apiRoutes.GET("/notifications", handler.GetNotifications)
+		apiRoutes.POST("/notifications/read-all", handler.MarkAllNotificationsRead)
+		apiRoutes.GET("/notifications/preferences", handler.GetNotificationPreferences)
+		apiRoutes.PUT("/notifications/preferences", handler.UpdateNotificationPreferences)
+		apiRoutes.POST("/notifications/:id/read", handler.MarkNotificationRead)
+		apiRoutes.DELETE("/notifications/:id", handler.DeleteNotification)
+
+		// Rute Webhook
+		apiRoutes.POST("/webhooks", handler.CreateWebhookEndpoint)
+		apiRoutes.GET("/webhooks", handler.GetWebhookEndpoints)
+		apiRoutes.PUT("/webhooks/:id", handler.UpdateWebhookEndpoint)
+		apiRoutes.DELETE("/webhooks/:id", handler.DeleteWebhookEndpoint)
+		apiRoutes.GET("/webhooks/:id/deliveries", handler.GetWebhookDeliveries)
+		apiRoutes.POST("/webhooks/:id/ping", handler.PingWebhookEndpoint)
+		apiRoutes.POST("/webhooks/:id/rotate-secret", handler.RotateWebhookSecret)
+
+		// Rute Stream Realtime (Server-Sent Events)
+		apiRoutes.POST("/stream/ticket", handler.CreateStreamTicket)
 
 		// Rute Kategori
 		apiRoutes.POST("/categories", handler.CreateCategory)
 		apiRoutes.GET("/categories", handler.GetCategories)
+		apiRoutes.POST("/categories/defaults", handler.ApplyDefaultCategories)
+		apiRoutes.PUT("/categories/reorder", handler.ReorderCategories)
 		apiRoutes.PUT("/categories/:id", handler.UpdateCategory)      
 		apiRoutes.DELETE("/categories/:id", handler.DeleteCategory)
+		apiRoutes.GET("/categories/:id/delete-preview", handler.GetCategoryDeletePreview)
+		apiRoutes.POST("/categories/:id/merge", handler.MergeCategory)
 
 		// Rute Sub-Kategori
 		apiRoutes.POST("/categories/:id/subcategories", handler.CreateSubCategory)
 		apiRoutes.GET("/categories/:id/subcategories", handler.GetSubCategoriesForCategory)
+		apiRoutes.PUT("/categories/:id/subcategories/reorder", handler.ReorderSubCategories)
 		apiRoutes.PUT("/subcategories/:id", handler.UpdateSubCategory)    
 		apiRoutes.DELETE("/subcategories/:id", handler.DeleteSubCategory)
+		apiRoutes.GET("/subcategories/:id/delete-preview", handler.GetSubCategoryDeletePreview)
+		apiRoutes.POST("/subcategories/:id/merge", handler.MergeSubCategory)
 		apiRoutes.GET("/categories/:id/allsubcategories", handler.GetAllSubCategoriesForCategory)
 
 		// Rute Transaksi
@@ -71,11 +186,45 @@ func main() {
         apiRoutes.GET("/transactions/:id", handler.GetTransactionByID)
         apiRoutes.DELETE("/transactions/:id", handler.DeleteTransaction)
         apiRoutes.PUT("/transactions/:id", handler.UpdateTransaction)
+        apiRoutes.POST("/transactions/:id/unlock", handler.UnlockTransaction)
+        apiRoutes.PUT("/transactions/:id/status", handler.UpdateTransactionStatus)
+
+		// Rute Payee
+		apiRoutes.POST("/payees", handler.CreatePayee)
+		apiRoutes.GET("/payees", handler.GetPayees)
+		apiRoutes.GET("/payees/totals", handler.GetPayeeTotals)
+		apiRoutes.PUT("/payees/:id", handler.UpdatePayee)
+		apiRoutes.DELETE("/payees/:id", handler.DeletePayee)
+
+		// Rute Target Tabungan
+		apiRoutes.POST("/savings-goals", handler.CreateSavingsGoal)
+		apiRoutes.GET("/savings-goals", handler.GetSavingsGoals)
+		apiRoutes.GET("/savings-goals/:id", handler.GetSavingsGoal)
+		apiRoutes.PUT("/savings-goals/:id", handler.UpdateSavingsGoal)
+		apiRoutes.DELETE("/savings-goals/:id", handler.DeleteSavingsGoal)
+		apiRoutes.POST("/savings-goals/:id/contributions", handler.AddSavingsContribution)
+		apiRoutes.DELETE("/savings-goals/:id/contributions/:contribution_id", handler.DeleteSavingsContribution)
+
+		// Rute Rekonsiliasi
+		apiRoutes.POST("/accounts/:id/reconciliations", handler.StartReconciliation)
+		apiRoutes.GET("/reconciliations/:id", handler.GetReconciliation)
+		apiRoutes.POST("/reconciliations/:id/transactions/:transaction_id/toggle", handler.ToggleReconciliationTransaction)
+		apiRoutes.POST("/reconciliations/:id/finish", handler.FinishReconciliation)
+		apiRoutes.DELETE("/reconciliations/:id", handler.CancelReconciliation)
 
 		// Rute Budget
     	apiRoutes.GET("/budgets", handler.GetBudgets)
     	apiRoutes.POST("/budgets", handler.SetBudgets)
 		apiRoutes.GET("/budgets/suggestions", handler.GetBudgetSuggestions)
+		apiRoutes.POST("/budgets/copy", handler.CopyBudgets)
+		apiRoutes.GET("/budgets/period", handler.GetBudgetPeriod)
+
+		// Rute Template Budget
+		apiRoutes.GET("/budget-templates", handler.GetBudgetTemplates)
+		apiRoutes.POST("/budget-templates", handler.CreateBudgetTemplate)
+		apiRoutes.PUT("/budget-templates/:id", handler.UpdateBudgetTemplate)
+		apiRoutes.DELETE("/budget-templates/:id", handler.DeleteBudgetTemplate)
+		apiRoutes.POST("/budget-templates/:id/apply", handler.ApplyBudgetTemplate)
 	}
 	router.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -0,0 +1,43 @@
+// Command recompute menghitung ulang saldo akun dari ledger transaksi dan
+// melaporkan akun yang saldonya tidak cocok.
+//
+//	go run ./cmd/recompute                  # laporan untuk semua user
+//	go run ./cmd/recompute -user 3 -fix balance
+//
+// -fix balance ditolak sampai server API sudah berjalan sekali dan mem-backfill saldo awal akun lama.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+)
+
+func main() {
+	userID := flag.Uint("user", 0, "only recompute accounts of this user ID (0 = all users)")
+	fix := flag.String("fix", ledger.FixNone, "how to fix discrepancies: \"balance\" or \"ledger\" (empty = report only)")
+	flag.Parse()
+
+	if *fix != ledger.FixNone && *fix != ledger.FixBalance && *fix != ledger.FixLedger {
+		log.Fatalf("invalid -fix value %q", *fix)
+	}
+
+	database.ConnectDatabase()
+
+	discrepancies, err := ledger.Recompute(database.DB, uint(*userID), *fix)
+	if err != nil {
+		log.Fatal("Failed to recompute balances:", err)
+	}
+
+	if len(discrepancies) == 0 {
+		fmt.Println("All account balances match the ledger.")
+		return
+	}
+	for _, d := range discrepancies {
+		fmt.Printf("account %d (%s, user %d): stored %.2f, ledger %.2f, difference %.2f, fixed=%t\n",
+			d.AccountID, d.AccountName, d.UserID, d.StoredBalance, d.LedgerBalance, d.Difference, d.Fixed)
+	}
+}
@@ -0,0 +1,78 @@
+// Package anomaly menandai pengeluaran yang tidak biasa: transaksi yang jauh di atas
+// kebiasaan user dan lonjakan pengeluaran dibanding rata-rata periode sebelumnya.
+package anomaly
+
+import "math"
+
+const (
+	// Jumlah transaksi pembanding minimum agar kebiasaan user bisa dinilai
+	MinBaselineCount = 5
+	// Transaksi dianggap tidak biasa jika di atas rata-rata + ZScoreThreshold simpangan baku
+	// dan sekaligus minimal OutlierRatio kali rata-rata
+	ZScoreThreshold = 3.0
+	OutlierRatio    = 2.0
+	// Lonjakan jika pengeluaran periode ini minimal SpikeRatio kali rata-rata periode sebelumnya
+	SpikeRatio = 1.5
+)
+
+// Baseline adalah statistik nominal transaksi pembanding
+type Baseline struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// NewBaseline menghitung rata-rata dan simpangan baku (populasi) dari nominal
+func NewBaseline(amounts []float64) Baseline {
+	baseline := Baseline{Count: len(amounts)}
+	if len(amounts) == 0 {
+		return baseline
+	}
+	for _, amount := range amounts {
+		baseline.Mean += amount
+	}
+	baseline.Mean /= float64(len(amounts))
+	variance := 0.0
+	for _, amount := range amounts {
+		variance += (amount - baseline.Mean) * (amount - baseline.Mean)
+	}
+	baseline.StdDev = math.Sqrt(variance / float64(len(amounts)))
+	baseline.Mean = round(baseline.Mean)
+	baseline.StdDev = round(baseline.StdDev)
+	return baseline
+}
+
+// Outlier memeriksa apakah amount jauh di atas baseline. Skor adalah z-score; jika
+// simpangan baku nol (semua nominal sama) skor memakai rasio terhadap rata-rata.
+func Outlier(amount float64, baseline Baseline) (float64, bool) {
+	if baseline.Count < MinBaselineCount || baseline.Mean <= 0 || amount < baseline.Mean*OutlierRatio {
+		return 0, false
+	}
+	if baseline.StdDev == 0 {
+		return round(amount / baseline.Mean), true
+	}
+	score := (amount - baseline.Mean) / baseline.StdDev
+	return math.Round(score*100) / 100, score >= ZScoreThreshold
+}
+
+// Spike memeriksa apakah total periode ini melonjak dibanding rata-rata periode sebelumnya
+// dan mengembalikan rasionya
+func Spike(current float64, previous []float64) (float64, bool) {
+	if len(previous) == 0 {
+		return 0, false
+	}
+	average := 0.0
+	for _, amount := range previous {
+		average += amount
+	}
+	average /= float64(len(previous))
+	if average <= 0 {
+		return 0, false
+	}
+	ratio := current / average
+	return math.Round(ratio*100) / 100, ratio >= SpikeRatio
+}
@@ -0,0 +1,178 @@
+// Package debt berisi perhitungan pinjaman: jadwal angsuran (amortisasi) dan
+// simulasi pelunasan beberapa utang sekaligus (snowball vs avalanche).
+package debt
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	StrategySnowball  = "snowball"  // lunasi saldo terkecil lebih dulu
+	StrategyAvalanche = "avalanche" // lunasi bunga tertinggi lebih dulu
+
+	// Batas simulasi agar utang yang cicilannya tidak menutup bunga tidak berputar selamanya
+	MaxSimulationMonths = 600
+)
+
+type Installment struct {
+	Number    int       `json:"number"`
+	DueDate   time.Time `json:"due_date"`
+	Payment   float64   `json:"payment"`
+	Principal float64   `json:"principal"`
+	Interest  float64   `json:"interest"`
+	Balance   float64   `json:"remaining_balance"`
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// MonthlyInterest menghitung bunga satu bulan dari saldo pokok dengan bunga tahunan dalam persen
+func MonthlyInterest(balance, annualRate float64) float64 {
+	return round(balance * annualRate / 12 / 100)
+}
+
+// MonthlyPayment menghitung angsuran tetap (anuitas) per bulan
+func MonthlyPayment(principal, annualRate float64, termMonths int) float64 {
+	if termMonths <= 0 {
+		return round(principal)
+	}
+	rate := annualRate / 12 / 100
+	if rate == 0 {
+		return round(principal / float64(termMonths))
+	}
+	return round(principal * rate / (1 - math.Pow(1+rate, -float64(termMonths))))
+}
+
+// DueDate mengembalikan tanggal jatuh tempo angsuran ke-number (mulai dari 1), yaitu
+// bulan-bulan setelah start pada paymentDay. Tanggal disesuaikan untuk bulan yang lebih pendek.
+func DueDate(start time.Time, paymentDay, number int) time.Time {
+	firstOfMonth := time.Date(start.Year(), start.Month()+time.Month(number), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if paymentDay > lastDay {
+		paymentDay = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, paymentDay-1)
+}
+
+// Schedule membuat jadwal angsuran lengkap. Angsuran terakhir disesuaikan agar sisa pokok tepat nol.
+func Schedule(principal, annualRate float64, termMonths int, start time.Time, paymentDay int) []Installment {
+	payment := MonthlyPayment(principal, annualRate, termMonths)
+	balance := round(principal)
+	schedule := make([]Installment, 0, termMonths)
+	for number := 1; number <= termMonths && balance > 0; number++ {
+		interest := MonthlyInterest(balance, annualRate)
+		principalPart := round(payment - interest)
+		if number == termMonths || principalPart > balance {
+			principalPart = balance
+		}
+		balance = round(balance - principalPart)
+		schedule = append(schedule, Installment{
+			Number:    number,
+			DueDate:   DueDate(start, paymentDay, number),
+			Payment:   round(principalPart + interest),
+			Principal: principalPart,
+			Interest:  interest,
+			Balance:   balance,
+		})
+	}
+	return schedule
+}
+
+// Debt adalah satu utang yang ikut disimulasikan. Balance bernilai positif.
+type Debt struct {
+	ID             uint    `json:"account_id"`
+	Name           string  `json:"name"`
+	Balance        float64 `json:"balance"`
+	AnnualRate     float64 `json:"annual_rate"`
+	MinimumPayment float64 `json:"minimum_payment"`
+}
+
+type DebtPayoff struct {
+	ID           uint    `json:"account_id"`
+	Name         string  `json:"name"`
+	PayoffMonth  int     `json:"payoff_month"` // 0 jika tidak lunas dalam batas simulasi
+	InterestPaid float64 `json:"interest_paid"`
+}
+
+type PayoffPlan struct {
+	Strategy      string       `json:"strategy"`
+	Months        int          `json:"months"`
+	TotalInterest float64      `json:"total_interest"`
+	TotalPaid     float64      `json:"total_paid"`
+	PaidOff       bool         `json:"paid_off"`
+	Debts         []DebtPayoff `json:"debts"` // urutan pelunasan sesuai strategi
+}
+
+// Simulate mensimulasikan pelunasan semua utang dengan anggaran bulanan tetap sebesar
+// total cicilan minimum ditambah extra. Cicilan minimum utang yang sudah lunas
+// dialihkan ke utang prioritas berikutnya.
+func Simulate(debts []Debt, extra float64, strategy string) PayoffPlan {
+	ordered := append([]Debt{}, debts...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if strategy == StrategyAvalanche && ordered[i].AnnualRate != ordered[j].AnnualRate {
+			return ordered[i].AnnualRate > ordered[j].AnnualRate
+		}
+		return ordered[i].Balance < ordered[j].Balance
+	})
+
+	plan := PayoffPlan{Strategy: strategy, Debts: make([]DebtPayoff, len(ordered))}
+	balances := make([]float64, len(ordered))
+	budget := extra
+	for i, debt := range ordered {
+		plan.Debts[i] = DebtPayoff{ID: debt.ID, Name: debt.Name}
+		balances[i] = round(debt.Balance)
+		budget += debt.MinimumPayment
+	}
+
+	remaining := func() bool {
+		for _, balance := range balances {
+			if balance > 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	for month := 1; month <= MaxSimulationMonths && remaining(); month++ {
+		plan.Months = month
+		available := budget
+		for i, debt := range ordered {
+			if balances[i] <= 0 {
+				continue
+			}
+			interest := MonthlyInterest(balances[i], debt.AnnualRate)
+			balances[i] = round(balances[i] + interest)
+			plan.Debts[i].InterestPaid = round(plan.Debts[i].InterestPaid + interest)
+			plan.TotalInterest = round(plan.TotalInterest + interest)
+		}
+		// Cicilan minimum dulu, sisanya ke utang prioritas
+		for i, debt := range ordered {
+			if balances[i] <= 0 {
+				continue
+			}
+			payment := math.Min(math.Min(debt.MinimumPayment, balances[i]), available)
+			balances[i] = round(balances[i] - payment)
+			available -= payment
+			plan.TotalPaid = round(plan.TotalPaid + payment)
+		}
+		for i := range ordered {
+			if balances[i] <= 0 || available <= 0 {
+				continue
+			}
+			payment := math.Min(balances[i], available)
+			balances[i] = round(balances[i] - payment)
+			available -= payment
+			plan.TotalPaid = round(plan.TotalPaid + payment)
+		}
+		for i := range ordered {
+			if balances[i] <= 0 && plan.Debts[i].PayoffMonth == 0 {
+				plan.Debts[i].PayoffMonth = month
+			}
+		}
+	}
+	plan.PaidOff = !remaining()
+	return plan
+}
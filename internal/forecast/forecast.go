@@ -0,0 +1,168 @@
+// Package forecast memproyeksikan saldo harian akun ke depan dari saldo awal, kejadian
+// terjadwal (tagihan, langganan, transaksi bertanggal masa depan) dan rata-rata harian.
+package forecast
+
+import (
+	"math"
+	"time"
+)
+
+// Sumber kejadian terjadwal
+const (
+	SourceScheduledTransaction = "scheduled_transaction"
+	SourceBill                 = "bill"
+	SourceSubscription         = "subscription"
+)
+
+// Event adalah perubahan saldo terjadwal pada satu tanggal
+type Event struct {
+	Date      time.Time `json:"date"`
+	AccountID *uint     `json:"account_id"` // nil: akun belum ditentukan, hanya mempengaruhi total
+	Source    string    `json:"source"`
+	SourceID  uint      `json:"source_id"`
+	Name      string    `json:"name"`
+	Amount    float64   `json:"amount"` // negatif = uang keluar
+}
+
+// Account adalah saldo awal dan rata-rata perubahan harian satu akun
+type Account struct {
+	ID           uint
+	Name         string
+	Balance      float64
+	DailyAverage float64 // negatif = rata-rata pengeluaran lebih besar dari pemasukan
+	Liquid       bool    // akun kas (bukan utang): ikut dihitung pada total dan diperingatkan jika negatif
+}
+
+type Point struct {
+	Date    time.Time `json:"date"`
+	Balance float64   `json:"balance"`
+	Inflow  float64   `json:"inflow"`
+	Outflow float64   `json:"outflow"`
+}
+
+type Projection struct {
+	AccountID         uint       `json:"account_id,omitempty"`
+	Name              string     `json:"name"`
+	StartingBalance   float64    `json:"starting_balance"`
+	EndingBalance     float64    `json:"ending_balance"`
+	LowestBalance     float64    `json:"lowest_balance"`
+	LowestBalanceDate time.Time  `json:"lowest_balance_date"`
+	FirstNegativeDate *time.Time `json:"first_negative_date"`
+	Daily             []Point    `json:"daily"`
+}
+
+// NegativeDate adalah tanggal di mana satu atau lebih akun diperkirakan bersaldo negatif
+type NegativeDate struct {
+	Date       time.Time `json:"date"`
+	AccountIDs []uint    `json:"account_ids"`
+}
+
+type Result struct {
+	Accounts      []Projection   `json:"accounts"`
+	Overall       Projection     `json:"overall"`
+	NegativeDates []NegativeDate `json:"negative_dates"`
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func (p *Projection) add(date time.Time, balance, inflow, outflow float64, warn bool) {
+	balance = round(balance)
+	p.Daily = append(p.Daily, Point{Date: date, Balance: balance, Inflow: round(inflow), Outflow: round(outflow)})
+	if len(p.Daily) == 1 || balance < p.LowestBalance {
+		p.LowestBalance = balance
+		p.LowestBalanceDate = date
+	}
+	if warn && balance < 0 && p.FirstNegativeDate == nil {
+		negative := date
+		p.FirstNegativeDate = &negative
+	}
+	p.EndingBalance = balance
+}
+
+// Project membuat proyeksi dari hari start (hari ke-0) sampai start+days. Hari ke-0 hanya
+// memuat kejadian yang jatuh pada atau sebelum start (mis. tagihan terlambat); rata-rata
+// harian mulai dihitung hari berikutnya. Total hanya memakai akun kas ditambah kejadian tanpa
+// akun, sehingga belanja kartu kredit baru mengurangi total saat kartunya dibayar.
+func Project(start time.Time, days int, accounts []Account, events []Event) Result {
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	byDay := make(map[int][]Event)
+	for _, event := range events {
+		day := int(math.Floor(event.Date.Sub(start).Hours() / 24))
+		if day > days {
+			continue
+		}
+		if day < 0 {
+			day = 0
+		}
+		byDay[day] = append(byDay[day], event)
+	}
+
+	result := Result{Accounts: make([]Projection, len(accounts)), NegativeDates: []NegativeDate{}}
+	balances := make([]float64, len(accounts))
+	index := make(map[uint]int, len(accounts))
+	overallBalance := 0.0
+	for i, account := range accounts {
+		index[account.ID] = i
+		balances[i] = account.Balance
+		if account.Liquid {
+			overallBalance += account.Balance
+		}
+		result.Accounts[i] = Projection{AccountID: account.ID, Name: account.Name, StartingBalance: round(account.Balance)}
+	}
+	result.Overall = Projection{Name: "overall", StartingBalance: round(overallBalance)}
+
+	for day := 0; day <= days; day++ {
+		date := start.AddDate(0, 0, day)
+		inflows := make([]float64, len(accounts))
+		outflows := make([]float64, len(accounts))
+		var overallInflow, overallOutflow float64
+		apply := func(i int, amount float64) {
+			if i >= 0 {
+				balances[i] += amount
+				if amount >= 0 {
+					inflows[i] += amount
+				} else {
+					outflows[i] -= amount
+				}
+				if !accounts[i].Liquid {
+					return
+				}
+			}
+			overallBalance += amount
+			if amount >= 0 {
+				overallInflow += amount
+			} else {
+				overallOutflow -= amount
+			}
+		}
+		if day > 0 {
+			for i, account := range accounts {
+				apply(i, account.DailyAverage)
+			}
+		}
+		for _, event := range byDay[day] {
+			i := -1
+			if event.AccountID != nil {
+				if found, ok := index[*event.AccountID]; ok {
+					i = found
+				}
+			}
+			apply(i, event.Amount)
+		}
+
+		negative := NegativeDate{Date: date}
+		for i, account := range accounts {
+			result.Accounts[i].add(date, balances[i], inflows[i], outflows[i], account.Liquid)
+			if account.Liquid && round(balances[i]) < 0 {
+				negative.AccountIDs = append(negative.AccountIDs, account.ID)
+			}
+		}
+		result.Overall.add(date, overallBalance, overallInflow, overallOutflow, true)
+		if len(negative.AccountIDs) > 0 {
+			result.NegativeDates = append(result.NegativeDates, negative)
+		}
+	}
+	return result
+}
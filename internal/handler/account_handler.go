@@ -1,17 +1,92 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"    
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AccountInput struct {
-	Name    string  `json:"name" binding:"required"`
-	Balance float64 `json:"balance"` 
+	Name        string   `json:"name" binding:"required"`
+	Type        string   `json:"type" binding:"omitempty,oneof=cash checking savings credit_card loan investment e_wallet"`
+	Balance     *float64 `json:"balance"` // saat create: saldo awal, saat update: dicatat sebagai adjustment
+	CreditLimit *float64 `json:"credit_limit" binding:"omitempty,gte=0"`
+	// Hanya saat create: tanggal saldo awal, default sekarang. Isi dengan tanggal sebelum
+	// transaksi lama yang akan dicatat mundur agar saldo awal berada di depan ledger.
+	OpeningDate *time.Time `json:"opening_date"`
+}
+
+type RecomputeBalancesInput struct {
+	Fix string `json:"fix" binding:"omitempty,oneof=balance ledger"`
+}
+
+// errDeleteStrategyRequired dikembalikan dari dalam transaksi penghapusan jika data masih
+// dipakai dan klien belum memilih strategi reassign/cascade
+var errDeleteStrategyRequired = errors.New("delete strategy required")
+
+// recordBalanceChange mencatat transaksi sistem (saldo awal / adjustment) untuk sebuah akun
+func recordBalanceChange(tx *gorm.DB, account model.Account, transactionType string, amount float64, notes string, date time.Time) error {
+	transaction := model.Transaction{
+		UserID:          account.UserID,
+		AccountID:       account.ID,
+		Amount:          amount,
+		Type:            transactionType,
+		Notes:           notes,
+		TransactionDate: date,
+		Status:          model.TransactionStatusCleared,
+	}
+	return tx.Create(&transaction).Error
+}
+
+// fillAccountBalances mengisi WorkingBalance dan ClearedBalance setiap akun.
+// Cleared balance = saldo saat ini tanpa efek transaksi yang masih pending.
+func fillAccountBalances(db *gorm.DB, accounts []model.Account) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(accounts))
+	for i, account := range accounts {
+		ids[i] = account.ID
+	}
+	var pending []model.Transaction
+	if err := db.Where("status = ? AND (account_id IN ? OR destination_account_id IN ?)", model.TransactionStatusPending, ids, ids).
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	for i := range accounts {
+		working := accounts[i].Balance
+		cleared := working
+		for _, transaction := range pending {
+			cleared -= ledger.Delta(transaction, accounts[i].ID)
+		}
+		cleared = roundMoney(cleared)
+		accounts[i].WorkingBalance = &working
+		accounts[i].ClearedBalance = &cleared
+	}
+	return nil
+}
+
+// validateAccountInput mengisi tipe default dan memastikan limit kredit hanya untuk akun liabilitas
+func validateAccountInput(input *AccountInput) error {
+	if input.Type == "" {
+		input.Type = model.AccountTypeCash
+	}
+	if input.CreditLimit != nil && !(model.Account{Type: input.Type}).IsLiability() {
+		return errors.New("credit_limit is only allowed for credit_card and loan accounts")
+	}
+	return nil
 }
 
 // --- Handler untuk Membuat Akun Baru ---
@@ -21,21 +96,45 @@ func CreateAccount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateAccountInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Ambil user dari context yang sudah di-set oleh middleware
 	currentUser, _ := c.Get("currentUser")
 	user := currentUser.(model.User)
 
 	account := model.Account{
-		UserID:  user.ID,
-		Name:    input.Name,
-		Balance: input.Balance,
+		UserID:                 user.ID,
+		Name:                   input.Name,
+		Type:                   input.Type,
+		CreditLimit:            input.CreditLimit,
+		OpeningBalanceRecorded: true,
+	}
+	if input.Balance != nil {
+		account.Balance = *input.Balance
 	}
 
-	if err := database.DB.Create(&account).Error; err != nil {
+	// Saldo awal dicatat sebagai transaksi agar saldo selalu bisa dihitung ulang dari ledger
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&account).Error; err != nil {
+			return err
+		}
+		if account.Balance == 0 {
+			return nil
+		}
+		openingDate := time.Now()
+		if input.OpeningDate != nil {
+			openingDate = *input.OpeningDate
+		}
+		return recordBalanceChange(tx, account, model.TransactionTypeOpeningBalance, account.Balance, "Opening balance", openingDate)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create account"})
 		return
 	}
+	publishEvent(user.ID, webhook.EventAccountCreated, account)
 
 	c.JSON(http.StatusOK, account)
 }
@@ -45,11 +144,25 @@ func GetAccounts(c *gin.Context) {
 	currentUser, _ := c.Get("currentUser")
 	user := currentUser.(model.User)
 
+	query := database.DB.Preload("User").Where("user_id = ?", user.ID)
+	// Akun yang diarsipkan disembunyikan kecuali diminta dengan ?include_archived=true
+	if c.Query("include_archived") != "true" {
+		query = query.Where("archived = ?", false)
+	}
+	// Filter opsional berdasarkan tipe, contoh: ?type=credit_card,loan
+	if accountType := c.Query("type"); accountType != "" {
+		query = query.Where("type IN ?", strings.Split(accountType, ","))
+	}
+
 	var accounts []model.Account
-	if err := database.DB.Preload("User").Where("user_id = ?", user.ID).Find(&accounts).Error; err != nil {
+	if err := query.Find(&accounts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve accounts"})
 		return
 	}
+	if err := fillAccountBalances(database.DB, accounts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate account balances"})
+		return
+	}
 
 	c.JSON(http.StatusOK, accounts)
 }
@@ -84,11 +197,32 @@ func UpdateAccount(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
         return
     }
+    if input.Type == "" {
+        input.Type = account.Type
+    }
+    if err := validateAccountInput(&input); err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        return
+    }
 
-    // Update dan simpan
+    // Update dan simpan. Saldo tidak ditimpa langsung, selisihnya dicatat sebagai adjustment
     account.Name = input.Name
-    account.Balance = input.Balance
-    database.DB.Save(&account)
+    account.Type = input.Type
+    account.CreditLimit = input.CreditLimit
+    err = database.DB.Transaction(func(tx *gorm.DB) error {
+        if input.Balance != nil && roundMoney(*input.Balance-account.Balance) != 0 {
+            if err := recordBalanceChange(tx, account, model.TransactionTypeAdjustment, roundMoney(*input.Balance-account.Balance), "Balance adjustment", time.Now()); err != nil {
+                return err
+            }
+            account.Balance = *input.Balance
+        }
+        return tx.Save(&account).Error
+    })
+    if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+        return
+    }
+    publishEvent(user.ID, webhook.EventAccountUpdated, account)
 
     c.JSON(http.StatusOK, account)
 }
@@ -115,7 +249,213 @@ func DeleteAccount(c *gin.Context) {
         return
     }
 
-    database.DB.Delete(&account)
+    strategy := c.Query("strategy")
+    var transactionCount int
+    var transactions []model.Transaction
+    var target *model.Account
+    err = database.DB.Transaction(func(tx *gorm.DB) error {
+        // Kunci akun agar transaksi baru tidak bisa masuk di antara pengecekan dan penghapusan
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, account.ID).Error; err != nil {
+            return err
+        }
+        if err := accountTransactions(tx, account.ID).Find(&transactions).Error; err != nil {
+            return err
+        }
+        // Akun yang masih punya transaksi hanya boleh dihapus dengan strategi eksplisit
+        if len(transactions) > 0 && strategy == "" {
+            transactionCount = len(transactions)
+            return errDeleteStrategyRequired
+        }
+        for _, transaction := range transactions {
+            if transaction.Status == model.TransactionStatusReconciled {
+                return errLockedTransaction
+            }
+        }
+
+        switch strategy {
+        case "", "cascade":
+            if err := deleteTransactions(tx, transactions); err != nil {
+                return err
+            }
+        case "reassign":
+            reassigned, err := reassignAccountTransactions(tx, account, c.Query("target_account_id"), transactions)
+            if err != nil {
+                return err
+            }
+            target = &reassigned
+        default:
+            return &transactionError{Message: "strategy must be reassign or cascade"}
+        }
+
+        if err := tx.Where("account_id = ?", account.ID).Delete(&model.Reconciliation{}).Error; err != nil {
+            return err
+        }
+        if err := replacePayeeDefaults(tx, "default_account_id", []uint{account.ID}, nil); err != nil {
+            return err
+        }
+        if err := replaceRecurringReferences(tx, "account_id", []uint{account.ID}, nil); err != nil {
+            return err
+        }
+        if err := tx.Where("account_id = ?", account.ID).Delete(&model.LoanDetail{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Where("account_id = ?", account.ID).Delete(&model.CreditCardDetail{}).Error; err != nil {
+            return err
+        }
+        if err := tx.Exec("DELETE FROM savings_goal_accounts WHERE account_id = ?", account.ID).Error; err != nil {
+            return err
+        }
+        // Kontribusi tabungan ikut pindah bersama transaksinya saat reassign
+        var contributionAccountID *uint
+        if target != nil {
+            contributionAccountID = &target.ID
+        }
+        if err := tx.Model(&model.SavingsGoalContribution{}).Where("account_id = ?", account.ID).Update("account_id", contributionAccountID).Error; err != nil {
+            return err
+        }
+        return tx.Delete(&account).Error
+    })
+    if errors.Is(err, errDeleteStrategyRequired) {
+        c.JSON(http.StatusConflict, gin.H{
+            "error":             "Account has transactions, use strategy=reassign with target_account_id, strategy=cascade, or archive the account instead",
+            "transaction_count": transactionCount,
+        })
+        return
+    }
+    var validationErr *transactionError
+    if errors.As(err, &validationErr) {
+        respondTransactionError(c, err)
+        return
+    }
+    if err != nil {
+        log.Println("Failed to delete account:", err)
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+        return
+    }
+    if target != nil {
+        publishTransactionsUpdated(database.DB, user.ID, transactions)
+        publishAccountsUpdated(database.DB, user.ID, []uint{target.ID})
+    } else {
+        publishTransactionsDeleted(database.DB, user.ID, transactions)
+    }
+    publishEvent(user.ID, webhook.EventAccountDeleted, gin.H{"id": account.ID, "strategy": strategy})
 
     c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// reassignAccountTransactions memindahkan semua transaksi ke akun target dan
+// menambahkan efek saldonya ke akun target, lalu mengembalikan akun target.
+func reassignAccountTransactions(tx *gorm.DB, account model.Account, targetParam string, transactions []model.Transaction) (model.Account, error) {
+	var target model.Account
+	targetID, err := strconv.Atoi(targetParam)
+	if err != nil {
+		return target, &transactionError{Message: "target_account_id is required for strategy=reassign"}
+	}
+	if err := tx.Where("id = ? AND user_id = ?", targetID, account.UserID).First(&target).Error; err != nil {
+		return target, &transactionError{Message: "target account not found"}
+	}
+	if target.ID == account.ID {
+		return target, &transactionError{Message: "target account must be different from the deleted account"}
+	}
+	if target.Archived {
+		return target, &transactionError{Message: "target account is archived"}
+	}
+
+	var delta float64
+	for _, transaction := range transactions {
+		// Transfer antara akun ini dan target akan menjadi transfer ke diri sendiri
+		if transaction.Type == model.TransactionTypeTransfer && transaction.DestinationAccountID != nil &&
+			(transaction.AccountID == target.ID || *transaction.DestinationAccountID == target.ID) {
+			return target, &transactionError{Message: "transfers between this account and the target account cannot be reassigned"}
+		}
+		delta += ledger.Delta(transaction, account.ID)
+	}
+
+	if err := tx.Model(&model.Transaction{}).Where("account_id = ?", account.ID).Update("account_id", target.ID).Error; err != nil {
+		return target, err
+	}
+	if err := tx.Model(&model.Transaction{}).Where("destination_account_id = ?", account.ID).Update("destination_account_id", target.ID).Error; err != nil {
+		return target, err
+	}
+	return target, tx.Model(&target).Update("balance", roundMoney(target.Balance+delta)).Error
+}
+
+// --- Handler untuk Mengarsipkan / Mengaktifkan Kembali Akun ---
+// Akun yang diarsipkan disembunyikan dari daftar akun tetapi riwayatnya tetap ada.
+func ArchiveAccount(c *gin.Context) {
+	setAccountArchived(c, true)
+}
+
+func UnarchiveAccount(c *gin.Context) {
+	setAccountArchived(c, false)
+}
+
+func setAccountArchived(c *gin.Context, archived bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var account model.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+	account.Archived = archived
+	if err := database.DB.Save(&account).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+	publishEvent(currentUser.ID, webhook.EventAccountUpdated, account)
+
+	c.JSON(http.StatusOK, account)
+}
+
+// publishRecomputeFixes mengirim event untuk selisih yang diperbaiki: account.updated untuk
+// saldo yang ditimpa dan transaction.created untuk transaksi adjustment yang dicatat
+func publishRecomputeFixes(userID uint, discrepancies []ledger.Discrepancy) {
+	accountIDs := []uint{}
+	for _, discrepancy := range discrepancies {
+		if !discrepancy.Fixed {
+			continue
+		}
+		if discrepancy.AdjustmentTransactionID == nil {
+			accountIDs = append(accountIDs, discrepancy.AccountID)
+			continue
+		}
+		var adjustment model.Transaction
+		if err := database.DB.First(&adjustment, *discrepancy.AdjustmentTransactionID).Error; err != nil {
+			log.Println("Failed to load adjustment transaction:", err)
+			continue
+		}
+		publishEvent(userID, webhook.EventTransactionCreated, adjustment)
+	}
+	publishAccountsUpdated(database.DB, userID, accountIDs)
+}
+
+// --- Handler untuk Menghitung Ulang Saldo Semua Akun dari Ledger ---
+// Tanpa "fix" hanya melaporkan selisih. fix=balance menimpa saldo dengan hasil ledger,
+// fix=ledger mencatat transaksi adjustment agar ledger cocok dengan saldo tersimpan.
+func RecomputeAccountBalances(c *gin.Context) {
+	var input RecomputeBalancesInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	discrepancies, err := ledger.Recompute(database.DB, currentUser.ID, input.Fix)
+	if errors.Is(err, ledger.ErrOpeningBalancesMissing) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Opening balances of existing accounts are not backfilled yet, fix=balance is unavailable"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recompute balances"})
+		return
+	}
+	publishRecomputeFixes(currentUser.ID, discrepancies)
+
+	c.JSON(http.StatusOK, gin.H{"discrepancies": discrepancies})
 }
\ No newline at end of file
@@ -10,12 +10,14 @@ import (
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database" 
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"    
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type RegisterInput struct {
 	Name     string `json:"name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
+	Language string `json:"language" binding:"omitempty,oneof=en id"`
 }
 
 type LoginInput struct {
@@ -36,13 +38,25 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	if input.Language == "" {
+		input.Language = defaultLanguage
+	}
 	user := model.User{
 		Name:         input.Name,
 		Email:        input.Email,
 		PasswordHash: string(hashedPassword),
+		Language:     input.Language,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
+	// User baru langsung mendapat kategori bawaan agar bisa mencatat transaksi
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		_, err := seedDefaultCategories(tx, user.ID, user.Language)
+		return err
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
@@ -0,0 +1,581 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Status jatuh tempo tagihan pada kalender
+const (
+	BillStatusPaid     = "paid"
+	BillStatusOverdue  = "overdue"
+	BillStatusDueToday = "due_today"
+	BillStatusUpcoming = "upcoming"
+)
+
+// Batas perulangan saat menghitung jatuh tempo, cukup untuk tagihan mingguan puluhan tahun
+const maxBillOccurrences = 5000
+
+type BillInput struct {
+	Name               string     `json:"name" binding:"required,max=255"`
+	Amount             float64    `json:"amount" binding:"required,gt=0"`
+	IsEstimate         bool       `json:"is_estimate"`
+	Frequency          string     `json:"frequency" binding:"required,oneof=once weekly monthly yearly"`
+	Interval           int        `json:"interval" binding:"omitempty,min=1,max=120"`
+	StartDate          time.Time  `json:"start_date" binding:"required"`
+	EndDate            *time.Time `json:"end_date"`
+	AccountID          *uint      `json:"account_id"`
+	SubCategoryID      *uint      `json:"sub_category_id"`
+	ReminderDaysBefore *int       `json:"reminder_days_before" binding:"omitempty,min=0,max=60"`
+	Active             *bool      `json:"active"`
+}
+
+type PayBillInput struct {
+	DueDate       *time.Time `json:"due_date"`       // default: jatuh tempo paling awal yang belum dibayar
+	TransactionID *uint      `json:"transaction_id"` // tautkan transaksi yang sudah ada alih-alih membuat baru
+	AccountID     *uint      `json:"account_id"`
+	SubCategoryID *uint      `json:"sub_category_id"`
+	Amount        *float64   `json:"amount" binding:"omitempty,gt=0"`
+	Date          *time.Time `json:"date"`
+	Notes         string     `json:"notes"`
+}
+
+type BillOccurrence struct {
+	BillID        uint      `json:"bill_id"`
+	Name          string    `json:"name"`
+	DueDate       time.Time `json:"due_date"`
+	Amount        float64   `json:"amount"`
+	IsEstimate    bool      `json:"is_estimate"`
+	Status        string    `json:"status"`
+	AccountID     *uint     `json:"account_id"`
+	SubCategoryID *uint     `json:"sub_category_id"`
+	PaymentID     *uint     `json:"payment_id"`
+	TransactionID *uint     `json:"transaction_id"`
+}
+
+func truncateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// billDueDate mengembalikan jatuh tempo ke-n (mulai 0) sesuai aturan tagihan
+func billDueDate(bill model.Bill, n int) time.Time {
+	start := truncateDate(bill.StartDate)
+	interval := bill.Interval
+	if interval < 1 {
+		interval = 1
+	}
+	switch bill.Frequency {
+	case model.BillFrequencyWeekly:
+		return start.AddDate(0, 0, 7*n*interval)
+	case model.BillFrequencyYearly:
+		return dayInMonth(start.Year()+n*interval, start.Month(), start.Day())
+	default:
+		return dayInMonth(start.Year(), start.Month()+time.Month(n*interval), start.Day())
+	}
+}
+
+// billOccurrences menghitung semua jatuh tempo tagihan di antara from dan to (inklusif)
+func billOccurrences(bill model.Bill, from, to time.Time) []time.Time {
+	dates := []time.Time{}
+	for n := 0; n < maxBillOccurrences; n++ {
+		due := billDueDate(bill, n)
+		if due.After(to) || (bill.EndDate != nil && due.After(truncateDate(*bill.EndDate))) {
+			break
+		}
+		if !due.Before(from) {
+			dates = append(dates, due)
+		}
+		if bill.Frequency == model.BillFrequencyOnce {
+			break
+		}
+	}
+	return dates
+}
+
+func billStatus(due, today time.Time, paid bool) string {
+	switch {
+	case paid:
+		return BillStatusPaid
+	case due.Before(today):
+		return BillStatusOverdue
+	case due.Equal(today):
+		return BillStatusDueToday
+	default:
+		return BillStatusUpcoming
+	}
+}
+
+// billPaymentsByDate mengambil pembayaran tagihan dengan kunci tanggal jatuh tempo
+func billPaymentsByDate(db *gorm.DB, billIDs []uint) (map[uint]map[string]model.BillPayment, error) {
+	result := make(map[uint]map[string]model.BillPayment)
+	if len(billIDs) == 0 {
+		return result, nil
+	}
+	var payments []model.BillPayment
+	if err := db.Where("bill_id IN ?", billIDs).Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	for _, payment := range payments {
+		if result[payment.BillID] == nil {
+			result[payment.BillID] = make(map[string]model.BillPayment)
+		}
+		result[payment.BillID][payment.DueDate.Format("2006-01-02")] = payment
+	}
+	return result, nil
+}
+
+func validateBillInput(tx *gorm.DB, userID uint, input BillInput) error {
+	if input.EndDate != nil && input.EndDate.Before(input.StartDate) {
+		return &transactionError{Message: "end_date must not be before start_date"}
+	}
+	if input.AccountID != nil {
+		var count int64
+		tx.Model(&model.Account{}).Where("id = ? AND user_id = ?", *input.AccountID, userID).Count(&count)
+		if count == 0 {
+			return &transactionError{Message: "account not found"}
+		}
+	}
+	if input.SubCategoryID != nil {
+		return validateSubCategory(tx, userID, input.SubCategoryID, model.TransactionTypeExpense)
+	}
+	return nil
+}
+
+func applyBillInput(bill *model.Bill, input BillInput) {
+	bill.Name = input.Name
+	bill.Amount = input.Amount
+	bill.IsEstimate = input.IsEstimate
+	bill.Frequency = input.Frequency
+	bill.Interval = input.Interval
+	if bill.Interval == 0 {
+		bill.Interval = 1
+	}
+	bill.StartDate = truncateDate(input.StartDate)
+	bill.EndDate = input.EndDate
+	bill.AccountID = input.AccountID
+	bill.SubCategoryID = input.SubCategoryID
+	if input.ReminderDaysBefore != nil {
+		bill.ReminderDaysBefore = *input.ReminderDaysBefore
+	}
+	if input.Active != nil {
+		bill.Active = *input.Active
+	}
+}
+
+// createBill menyimpan tagihan baru
+func createBill(db *gorm.DB, bill *model.Bill) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Account", "SubCategory").Create(bill).Error; err != nil {
+			return err
+		}
+		// Nilai nol (active=false, reminder_days_before=0) dilewati GORM saat insert karena ada default kolom
+		return tx.Model(bill).Select("Active", "ReminderDaysBefore").Updates(bill).Error
+	})
+}
+
+// replaceRecurringReferences mengganti (atau mengosongkan jika replacement nil) akun/sub-kategori
+// pada tagihan dan langganan terdeteksi yang akan dihapus atau digabung.
+func replaceRecurringReferences(tx *gorm.DB, column string, ids []uint, replacement *uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Model(&model.Bill{}).Where(column+" IN ?", ids).Update(column, replacement).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.DetectedSubscription{}).Where(column+" IN ?", ids).Update(column, replacement).Error
+}
+
+// findBill mengambil tagihan milik user
+func findBill(c *gin.Context) (model.Bill, bool) {
+	var bill model.Bill
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return bill, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&bill).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill not found"})
+		return bill, false
+	}
+	return bill, true
+}
+
+// --- Handler untuk Membuat Tagihan ---
+func CreateBill(c *gin.Context) {
+	var input BillInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := validateBillInput(database.DB, currentUser.ID, input); err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	bill := model.Bill{UserID: currentUser.ID, ReminderDaysBefore: 3, Active: true}
+	applyBillInput(&bill, input)
+	if err := createBill(database.DB, &bill); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create bill"})
+		return
+	}
+	c.JSON(http.StatusOK, bill)
+}
+
+// --- Handler untuk Mengambil Semua Tagihan ---
+func GetBills(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	query := database.DB.Preload("Account").Preload("SubCategory").Where("user_id = ?", currentUser.ID)
+	if c.Query("include_inactive") != "true" {
+		query = query.Where("active = ?", true)
+	}
+	var bills []model.Bill
+	if err := query.Order("name asc").Find(&bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bills"})
+		return
+	}
+	c.JSON(http.StatusOK, bills)
+}
+
+// --- Handler untuk Mengubah Tagihan ---
+func UpdateBill(c *gin.Context) {
+	bill, ok := findBill(c)
+	if !ok {
+		return
+	}
+	var input BillInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBillInput(database.DB, bill.UserID, input); err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+	applyBillInput(&bill, input)
+	if err := database.DB.Omit("Account", "SubCategory").Save(&bill).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update bill"})
+		return
+	}
+	c.JSON(http.StatusOK, bill)
+}
+
+// --- Handler untuk Menghapus Tagihan (transaksi pembayarannya tetap ada) ---
+func DeleteBill(c *gin.Context) {
+	bill, ok := findBill(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bill_id = ?", bill.ID).Delete(&model.BillPayment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("bill_id = ?", bill.ID).Delete(&model.BillReminder{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.DetectedSubscription{}).Where("bill_id = ?", bill.ID).Update("bill_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&bill).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bill"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bill deleted successfully"})
+}
+
+// --- Handler untuk Kalender Tagihan (jatuh tempo yang akan datang & terlambat) ---
+// ?from=&to= berformat YYYY-MM-DD, default 30 hari ke belakang sampai 30 hari ke depan.
+func GetBillCalendar(c *gin.Context) {
+	today := truncateDate(time.Now())
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+		return
+	}
+	start, end := today.AddDate(0, 0, -30), today.AddDate(0, 0, 30)
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+	if end.Before(start) || end.Sub(start) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range must be between 0 and 366 days"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var bills []model.Bill
+	if err := database.DB.Where("user_id = ? AND active = ?", currentUser.ID, true).Find(&bills).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bills"})
+		return
+	}
+	billIDs := make([]uint, 0, len(bills))
+	for _, bill := range bills {
+		billIDs = append(billIDs, bill.ID)
+	}
+	payments, err := billPaymentsByDate(database.DB, billIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bill payments"})
+		return
+	}
+
+	occurrences := []BillOccurrence{}
+	for _, bill := range bills {
+		for _, due := range billOccurrences(bill, start, end) {
+			occurrence := BillOccurrence{
+				BillID:        bill.ID,
+				Name:          bill.Name,
+				DueDate:       due,
+				Amount:        bill.Amount,
+				IsEstimate:    bill.IsEstimate,
+				AccountID:     bill.AccountID,
+				SubCategoryID: bill.SubCategoryID,
+			}
+			payment, paid := payments[bill.ID][due.Format("2006-01-02")]
+			if paid {
+				occurrence.Amount = payment.Amount
+				occurrence.IsEstimate = false
+				occurrence.PaymentID = &payment.ID
+				occurrence.TransactionID = payment.TransactionID
+			}
+			occurrence.Status = billStatus(due, today, paid)
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].DueDate.Before(occurrences[j].DueDate)
+	})
+	c.JSON(http.StatusOK, occurrences)
+}
+
+// --- Handler untuk Menandai Tagihan Lunas ---
+// Membuat transaksi pengeluaran baru atau menautkan transaksi yang sudah ada (transaction_id).
+func PayBill(c *gin.Context) {
+	bill, ok := findBill(c)
+	if !ok {
+		return
+	}
+	var input PayBillInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payments, err := billPaymentsByDate(database.DB, []uint{bill.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bill payments"})
+		return
+	}
+	var dueDate time.Time
+	if input.DueDate != nil {
+		dueDate = truncateDate(*input.DueDate)
+		occurrences := billOccurrences(bill, dueDate, dueDate)
+		if len(occurrences) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "due_date is not a due date of this bill"})
+			return
+		}
+	} else {
+		// Jatuh tempo paling awal yang belum dibayar, sampai satu tahun ke depan
+		for _, due := range billOccurrences(bill, truncateDate(bill.StartDate), truncateDate(time.Now()).AddDate(1, 0, 0)) {
+			if _, paid := payments[bill.ID][due.Format("2006-01-02")]; !paid {
+				dueDate = due
+				break
+			}
+		}
+		if dueDate.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bill has no unpaid due dates"})
+			return
+		}
+	}
+	if _, paid := payments[bill.ID][dueDate.Format("2006-01-02")]; paid {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bill is already paid for this due date"})
+		return
+	}
+
+	payment := model.BillPayment{BillID: bill.ID, DueDate: dueDate}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.TransactionID != nil {
+			var transaction model.Transaction
+			if err := tx.Where("id = ? AND user_id = ?", *input.TransactionID, bill.UserID).First(&transaction).Error; err != nil {
+				return &transactionError{Message: "transaction not found"}
+			}
+			if transaction.Type != model.TransactionTypeExpense {
+				return &transactionError{Message: "only expense transactions can be linked to a bill"}
+			}
+			var linked int64
+			tx.Model(&model.BillPayment{}).Where("transaction_id = ?", transaction.ID).Count(&linked)
+			if linked > 0 {
+				return &transactionError{Message: "transaction is already linked to a bill payment"}
+			}
+			payment.Amount = transaction.Amount
+			payment.TransactionID = &transaction.ID
+			return tx.Create(&payment).Error
+		}
+
+		transactionInput := TransactionInput{
+			SubCategoryID:   bill.SubCategoryID,
+			Amount:          bill.Amount,
+			Type:            model.TransactionTypeExpense,
+			Notes:           "Bill: " + bill.Name,
+			TransactionDate: time.Now(),
+		}
+		if bill.AccountID != nil {
+			transactionInput.AccountID = *bill.AccountID
+		}
+		if input.AccountID != nil {
+			transactionInput.AccountID = *input.AccountID
+		}
+		if input.SubCategoryID != nil {
+			transactionInput.SubCategoryID = input.SubCategoryID
+		}
+		if input.Amount != nil {
+			transactionInput.Amount = *input.Amount
+		}
+		if input.Date != nil {
+			transactionInput.TransactionDate = *input.Date
+		}
+		if input.Notes != "" {
+			transactionInput.Notes = input.Notes
+		}
+		transaction, err := createTransaction(tx, bill.UserID, transactionInput)
+		if err != nil {
+			return err
+		}
+		payment.Amount = transaction.Amount
+		payment.TransactionID = &transaction.ID
+		return tx.Create(&payment).Error
+	})
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+	if input.TransactionID == nil {
+		var transaction model.Transaction
+		if database.DB.First(&transaction, *payment.TransactionID).Error == nil {
+			notifyBudgetThresholds(c.MustGet("currentUser").(model.User), transaction)
+			publishEvent(transaction.UserID, webhook.EventTransactionCreated, transaction)
+		}
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// --- Handler untuk Membatalkan Tanda Lunas (transaksinya tidak dihapus) ---
+func DeleteBillPayment(c *gin.Context) {
+	bill, ok := findBill(c)
+	if !ok {
+		return
+	}
+	paymentID, err := strconv.Atoi(c.Param("payment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID"})
+		return
+	}
+	result := database.DB.Where("id = ? AND bill_id = ?", paymentID, bill.ID).Delete(&model.BillPayment{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete bill payment"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bill payment not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Bill payment removed successfully"})
+}
+
+// SendBillReminders mengirim pengingat untuk tagihan yang jatuh tempo dalam
+// ReminderDaysBefore hari dan pemberitahuan untuk tagihan yang terlambat.
+// Setiap jatuh tempo hanya diingatkan sekali per jenis pengingat.
+func SendBillReminders(db *gorm.DB, dispatcher *notify.Dispatcher, now time.Time) (int, error) {
+	today := truncateDate(now)
+	var bills []model.Bill
+	if err := db.Where("active = ?", true).Find(&bills).Error; err != nil {
+		return 0, err
+	}
+	billIDs := make([]uint, 0, len(bills))
+	for _, bill := range bills {
+		billIDs = append(billIDs, bill.ID)
+	}
+	payments, err := billPaymentsByDate(db, billIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, bill := range bills {
+		// Hanya keterlambatan 7 hari terakhir yang diberitahukan agar tagihan lama tidak membanjiri
+		for _, due := range billOccurrences(bill, today.AddDate(0, 0, -7), today.AddDate(0, 0, bill.ReminderDaysBefore)) {
+			if _, paid := payments[bill.ID][due.Format("2006-01-02")]; paid {
+				continue
+			}
+			kind := notify.KindBillReminder
+			subject := fmt.Sprintf("Bill %s is due on %s", bill.Name, due.Format("2006-01-02"))
+			if due.Before(today) {
+				kind = notify.KindBillOverdue
+				subject = fmt.Sprintf("Bill %s is overdue since %s", bill.Name, due.Format("2006-01-02"))
+			}
+
+			reminder := model.BillReminder{BillID: bill.ID, DueDate: due, Kind: kind, SentAt: now}
+			var count int64
+			if err := db.Model(&model.BillReminder{}).Where("bill_id = ? AND due_date = ? AND kind = ?", bill.ID, due, kind).Count(&count).Error; err != nil {
+				return sent, err
+			}
+			if count > 0 {
+				continue
+			}
+			delivered, err := dispatcher.Send(notify.Message{
+				UserID:  bill.UserID,
+				Kind:    kind,
+				Subject: subject,
+				Body:    fmt.Sprintf("%s: %.2f due on %s", bill.Name, bill.Amount, due.Format("2006-01-02")),
+				Data:    map[string]interface{}{"bill_id": bill.ID, "due_date": due.Format("2006-01-02"), "amount": bill.Amount},
+			})
+			if err != nil {
+				log.Println("Failed to send bill reminder:", err)
+				// Dicoba lagi di putaran berikutnya hanya jika tidak ada channel yang berhasil,
+				// agar channel yang sudah menerima tidak mendapat pengingat yang sama berulang kali
+				if delivered == 0 {
+					continue
+				}
+			}
+			if err := db.Create(&reminder).Error; err != nil {
+				return sent, err
+			}
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// StartBillReminders menjalankan SendBillReminders secara berkala di background
+func StartBillReminders(interval time.Duration) {
+	go func() {
+		for {
+			if _, err := SendBillReminders(database.DB, notify.Default, time.Now()); err != nil {
+				log.Println("Failed to process bill reminders:", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"gorm.io/gorm"
+)
+
+// Ambang pemakaian budget (persen) yang diberitahukan, dari kecil ke besar
+var budgetAlertThresholds = []int{80, 100}
+
+type budgetCheck struct {
+	CategoryID uint
+	Period     periodKey
+}
+
+// budgetChecksFor menentukan budget (kategori + periode) yang terdampak oleh transaksi pengeluaran
+func budgetChecksFor(db *gorm.DB, user model.User, transactions []model.Transaction) ([]budgetCheck, error) {
+	checks := []budgetCheck{}
+	seen := make(map[budgetCheck]bool)
+	for _, transaction := range transactions {
+		if transaction.Type != model.TransactionTypeExpense || transaction.SubCategoryID == nil {
+			continue
+		}
+		var subCategory model.SubCategory
+		if err := db.Select("id", "category_id").First(&subCategory, *transaction.SubCategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		check := budgetCheck{CategoryID: subCategory.CategoryID, Period: periodContaining(user, transaction.TransactionDate)}
+		if !seen[check] {
+			seen[check] = true
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// checkBudgetThresholds memberitahukan budget yang pemakaiannya baru melewati 80% atau 100%.
+// Jika beberapa ambang terlewati sekaligus, hanya ambang tertinggi yang diberitahukan. Ambang
+// yang tidak lagi tercapai (mis. transaksi dihapus) dilepas agar bisa diberitahukan lagi.
+func checkBudgetThresholds(db *gorm.DB, dispatcher *notify.Dispatcher, user model.User, transactions ...model.Transaction) error {
+	checks, err := budgetChecksFor(db, user, transactions)
+	if err != nil {
+		return err
+	}
+	for _, check := range checks {
+		var budget model.Budget
+		err := db.Preload("Category").Where("user_id = ? AND category_id = ? AND year = ? AND month = ? AND week = ?",
+			user.ID, check.CategoryID, check.Period.Year, check.Period.Month, check.Period.Week).First(&budget).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if budget.Amount <= 0 {
+			continue
+		}
+
+		start, end := periodRange(user, check.Period)
+		var spent float64
+		if err := db.Model(&model.Transaction{}).
+			Select("COALESCE(SUM(transactions.amount), 0)").
+			Joins("JOIN sub_categories ON sub_categories.id = transactions.sub_category_id").
+			Where("transactions.user_id = ? AND transactions.type = ? AND sub_categories.category_id = ?", user.ID, model.TransactionTypeExpense, check.CategoryID).
+			Where("transactions.transaction_date >= ? AND transactions.transaction_date < ?", start, end).
+			Scan(&spent).Error; err != nil {
+			return err
+		}
+		percent := spent / budget.Amount * 100
+
+		var alerts []model.BudgetAlert
+		if err := db.Where("user_id = ? AND category_id = ? AND year = ? AND month = ? AND week = ?",
+			user.ID, check.CategoryID, check.Period.Year, check.Period.Month, check.Period.Week).Find(&alerts).Error; err != nil {
+			return err
+		}
+		sent := make(map[int]bool, len(alerts))
+		for _, alert := range alerts {
+			sent[alert.Threshold] = true
+		}
+
+		crossed := 0
+		var newlyReached []int
+		for _, threshold := range budgetAlertThresholds {
+			reached := percent >= float64(threshold)
+			switch {
+			case reached && !sent[threshold]:
+				newlyReached = append(newlyReached, threshold)
+				crossed = threshold
+			case !reached && sent[threshold]:
+				if err := db.Where("user_id = ? AND category_id = ? AND year = ? AND month = ? AND week = ? AND threshold = ?",
+					user.ID, check.CategoryID, check.Period.Year, check.Period.Month, check.Period.Week, threshold).Delete(&model.BudgetAlert{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if crossed == 0 {
+			continue
+		}
+
+		subject := fmt.Sprintf("You have used %d%% of your %s budget", crossed, budget.Category.Name)
+		if crossed >= 100 {
+			subject = fmt.Sprintf("You have exceeded your %s budget", budget.Category.Name)
+		}
+		delivered, err := dispatcher.Send(notify.Message{
+			UserID:  user.ID,
+			Kind:    notify.KindBudgetThreshold,
+			Subject: subject,
+			Body:    fmt.Sprintf("Spent %.2f of %.2f (%.0f%%) for %s between %s and %s", spent, budget.Amount, percent, budget.Category.Name, start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02")),
+			Data: map[string]interface{}{
+				"budget_id":   budget.ID,
+				"category_id": budget.CategoryID,
+				"threshold":   crossed,
+				"spent":       roundMoney(spent),
+				"amount":      budget.Amount,
+				"percent":     roundMoney(percent),
+			},
+		})
+		if err != nil {
+			log.Println("Failed to send budget alert:", err)
+		}
+		// Ambang baru dicatat hanya jika ada channel yang berhasil, agar dicoba lagi di
+		// pemeriksaan berikutnya jika semua channel gagal
+		if delivered > 0 {
+			for _, threshold := range newlyReached {
+				alert := model.BudgetAlert{UserID: user.ID, CategoryID: check.CategoryID, Year: check.Period.Year,
+					Month: check.Period.Month, Week: check.Period.Week, Threshold: threshold, SentAt: time.Now()}
+				if err := db.Create(&alert).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if crossed >= 100 {
+			publishEvent(user.ID, webhook.EventBudgetExceeded, map[string]interface{}{
+				"budget_id":   budget.ID,
+				"category_id": budget.CategoryID,
+				"year":        budget.Year,
+				"month":       budget.Month,
+				"week":        budget.Week,
+				"spent":       roundMoney(spent),
+				"amount":      budget.Amount,
+				"percent":     roundMoney(percent),
+			})
+		}
+	}
+	return nil
+}
+
+// notifyBudgetThresholds memeriksa ambang budget di background setelah transaksi berubah,
+// agar pengiriman email/webhook tidak memperlambat response
+func notifyBudgetThresholds(user model.User, transactions ...model.Transaction) {
+	go func() {
+		if err := checkBudgetThresholds(database.DB, notify.Default, user, transactions...); err != nil {
+			log.Println("Failed to check budget thresholds:", err)
+		}
+	}()
+}
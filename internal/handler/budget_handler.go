@@ -2,20 +2,22 @@
 package handler
 
 import (
+	"math"
 	"net/http"
-	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type BudgetInput struct {
 	CategoryID uint    `json:"category_id" binding:"required"`
 	Amount     float64 `json:"amount"`
-	Month      int     `json:"month" binding:"required"`
+	Month      int     `json:"month"` // wajib untuk periode bulanan
+	Week       int     `json:"week"`  // wajib untuk periode mingguan
 	Year       int     `json:"year" binding:"required"`
 }
 
@@ -26,49 +28,42 @@ type BudgetSuggestion struct {
 
 func GetBudgetSuggestions(c *gin.Context) {
 	currentUser := c.MustGet("currentUser").(model.User)
-	year, _ := strconv.Atoi(c.Query("year"))
-	month, _ := strconv.Atoi(c.Query("month"))
-
-	if year == 0 || month == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Year and month are required"})
+	key, err := periodKeyFromQuery(c, currentUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Tentukan periode bulan sebelumnya untuk dianalisis
-	currentMonthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	prevMonthStart := currentMonthStart.AddDate(0, -1, 0)
-	// prevMonthEnd := currentMonthStart.Add(-time.Second)
+	// Tentukan periode sebelumnya untuk dianalisis, mengikuti pengaturan periode user
+	prevStart, prevEnd := periodRange(currentUser, previousPeriod(currentUser, key))
 
 	var suggestions []BudgetSuggestion
 
-	// Query untuk menjumlahkan total pengeluaran per kategori dari bulan sebelumnya
+	// Query untuk menjumlahkan total pengeluaran per kategori dari periode sebelumnya
 	database.DB.Model(&model.Transaction{}).
 		Select("sub_categories.category_id, SUM(transactions.amount) as suggested_amount").
 		Joins("join sub_categories on sub_categories.id = transactions.sub_category_id").
 		Where("transactions.user_id = ? AND transactions.type = 'expense'", currentUser.ID).
-		Where("transactions.transaction_date >= ? AND transactions.transaction_date < ?", prevMonthStart, currentMonthStart).
+		Where("transactions.transaction_date >= ? AND transactions.transaction_date < ?", prevStart, prevEnd).
 		Group("sub_categories.category_id").
 		Scan(&suggestions)
 
 	c.JSON(http.StatusOK, suggestions)
 }
 
-// Handler untuk mendapatkan semua budget di bulan tertentu
+// Handler untuk mendapatkan semua budget di periode tertentu
 func GetBudgets(c *gin.Context) {
 	currentUser := c.MustGet("currentUser").(model.User)
-	year, _ := strconv.Atoi(c.Query("year"))
-	month, _ := strconv.Atoi(c.Query("month"))
-
-	if year == 0 || month == 0 {
-		// Jika tidak ada parameter, gunakan bulan saat ini
-		now := time.Now()
-		year = now.Year()
-		month = int(now.Month())
+	// Jika tidak ada parameter, gunakan periode saat ini
+	key, err := periodKeyFromQuery(c, currentUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	var budgets []model.Budget
 	database.DB.Preload("Category").
-		Where("user_id = ? AND year = ? AND month = ?", currentUser.ID, year, month).
+		Where("user_id = ? AND year = ? AND month = ? AND week = ?", currentUser.ID, key.Year, key.Month, key.Week).
 		Find(&budgets)
 
 	c.JSON(http.StatusOK, budgets)
@@ -86,26 +81,110 @@ func SetBudgets(c *gin.Context) {
 	var budgetsToUpsert []model.Budget
 
 	for _, input := range inputs {
-		budgetsToUpsert = append(budgetsToUpsert, model.Budget{
-			UserID:     currentUser.ID,
-			CategoryID: input.CategoryID,
-			Amount:     input.Amount,
-			Month:      input.Month,
-			Year:       input.Year,
-		})
-	}
-
-	// GORM "Upsert": Jika ada, update. Jika tidak ada, buat baru.
-	// Kita cocokkan berdasarkan unique index yang kita buat di model.
-	err := database.DB.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category_id"}, {Name: "month"}, {Name: "year"}},
-		DoUpdates: clause.AssignmentColumns([]string{"amount"}),
-	}).Create(&budgetsToUpsert).Error
+		key, err := normalizePeriodKey(currentUser, input.Year, input.Month, input.Week)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		budgetsToUpsert = append(budgetsToUpsert, newBudget(currentUser.ID, input.CategoryID, input.Amount, key))
+	}
 
-	if err != nil {
+	if err := upsertBudgets(database.DB, budgetsToUpsert); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set budgets"})
 		return
 	}
+	publishEvent(currentUser.ID, webhook.EventBudgetUpdated, gin.H{"budgets": budgetsToUpsert})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Budgets set successfully"})
+}
+
+func newBudget(userID, categoryID uint, amount float64, key periodKey) model.Budget {
+	return model.Budget{
+		UserID:     userID,
+		CategoryID: categoryID,
+		Amount:     amount,
+		Month:      key.Month,
+		Week:       key.Week,
+		Year:       key.Year,
+	}
+}
+
+// upsertBudgets menyimpan budget dengan GORM "Upsert": Jika ada, update. Jika tidak ada, buat baru.
+// Kita cocokkan berdasarkan unique index yang kita buat di model.
+func upsertBudgets(db *gorm.DB, budgets []model.Budget) error {
+	if len(budgets) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category_id"}, {Name: "month"}, {Name: "year"}, {Name: "week"}},
+		DoUpdates: clause.AssignmentColumns([]string{"amount"}),
+	}).Create(&budgets).Error
+}
+
+type CopyBudgetsInput struct {
+	SourceYear  int      `json:"source_year" binding:"required"`
+	SourceMonth int      `json:"source_month"`
+	SourceWeek  int      `json:"source_week"`
+	TargetYear  int      `json:"target_year" binding:"required"`
+	TargetMonth int      `json:"target_month"`
+	TargetWeek  int      `json:"target_week"`
+	Percentage  *float64 `json:"percentage" binding:"omitempty,gte=0"` // 100 = sama persis, 110 = naik 10%
+}
+
+// Handler untuk menyalin semua budget dari satu periode ke periode lain
+func CopyBudgets(c *gin.Context) {
+	var input CopyBudgetsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(model.User)
+	source, err := normalizePeriodKey(currentUser, input.SourceYear, input.SourceMonth, input.SourceWeek)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid source period: " + err.Error()})
+		return
+	}
+	target, err := normalizePeriodKey(currentUser, input.TargetYear, input.TargetMonth, input.TargetWeek)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target period: " + err.Error()})
+		return
+	}
+	if source == target {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Source and target period cannot be the same"})
+		return
+	}
+
+	scale := 1.0
+	if input.Percentage != nil {
+		scale = *input.Percentage / 100
+	}
+
+	var sourceBudgets []model.Budget
+	if err := database.DB.Where("user_id = ? AND year = ? AND month = ? AND week = ?", currentUser.ID, source.Year, source.Month, source.Week).Find(&sourceBudgets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve source budgets"})
+		return
+	}
+	if len(sourceBudgets) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No budgets found in source period"})
+		return
+	}
+
+	var budgetsToUpsert []model.Budget
+	for _, budget := range sourceBudgets {
+		budgetsToUpsert = append(budgetsToUpsert, newBudget(currentUser.ID, budget.CategoryID, roundMoney(budget.Amount*scale), target))
+	}
+
+	if err := upsertBudgets(database.DB, budgetsToUpsert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to copy budgets"})
+		return
+	}
+	publishEvent(currentUser.ID, webhook.EventBudgetUpdated, gin.H{"budgets": budgetsToUpsert})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budgets copied successfully", "copied": len(budgetsToUpsert)})
+}
+
+// roundMoney membulatkan nominal ke 2 angka desimal sesuai kolom decimal(15,2)
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
 }
\ No newline at end of file
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas jumlah periode yang bisa diisi sekaligus saat menerapkan template
+const maxTemplateApplyPeriods = 120
+
+type BudgetTemplateItemInput struct {
+	CategoryID uint    `json:"category_id" binding:"required"`
+	Amount     float64 `json:"amount" binding:"gte=0"`
+}
+
+type BudgetTemplateInput struct {
+	Name  string                    `json:"name" binding:"required"`
+	Items []BudgetTemplateItemInput `json:"items" binding:"dive"`
+	// Opsional: ambil isi template dari budget periode tertentu
+	FromYear  int `json:"from_year"`
+	FromMonth int `json:"from_month"`
+	FromWeek  int `json:"from_week"`
+}
+
+type ApplyBudgetTemplateInput struct {
+	StartYear  int      `json:"start_year" binding:"required"`
+	StartMonth int      `json:"start_month"`
+	StartWeek  int      `json:"start_week"`
+	EndYear    int      `json:"end_year"`
+	EndMonth   int      `json:"end_month"`
+	EndWeek    int      `json:"end_week"`
+	Percentage *float64 `json:"percentage" binding:"omitempty,gte=0"`
+}
+
+// buildTemplateItems menyusun item template dari input, atau dari budget periode sumber
+// jika item tidak diberikan. Semua kategori harus milik user.
+func buildTemplateItems(tx *gorm.DB, user model.User, input BudgetTemplateInput) ([]model.BudgetTemplateItem, error) {
+	userID := user.ID
+	var items []model.BudgetTemplateItem
+	if len(input.Items) == 0 && input.FromYear != 0 {
+		key, err := normalizePeriodKey(user, input.FromYear, input.FromMonth, input.FromWeek)
+		if err != nil {
+			return nil, err
+		}
+		var budgets []model.Budget
+		if err := tx.Where("user_id = ? AND year = ? AND month = ? AND week = ?", userID, key.Year, key.Month, key.Week).Find(&budgets).Error; err != nil {
+			return nil, err
+		}
+		for _, budget := range budgets {
+			items = append(items, model.BudgetTemplateItem{CategoryID: budget.CategoryID, Amount: budget.Amount})
+		}
+		return items, nil
+	}
+
+	for _, item := range input.Items {
+		var count int64
+		if err := tx.Model(&model.Category{}).Where("id = ? AND user_id = ?", item.CategoryID, userID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("category " + strconv.Itoa(int(item.CategoryID)) + " not found")
+		}
+		items = append(items, model.BudgetTemplateItem{CategoryID: item.CategoryID, Amount: item.Amount})
+	}
+	return items, nil
+}
+
+// --- Handler untuk Mendapatkan Semua Template Budget ---
+func GetBudgetTemplates(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var templates []model.BudgetTemplate
+	if err := database.DB.Preload("Items").Preload("Items.Category").Where("user_id = ?", currentUser.ID).Find(&templates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve budget templates"})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// --- Handler untuk Membuat Template Budget ---
+func CreateBudgetTemplate(c *gin.Context) {
+	var input BudgetTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	template := model.BudgetTemplate{UserID: currentUser.ID, Name: input.Name}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		items, err := buildTemplateItems(tx, currentUser, input)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errors.New("template must contain at least one item")
+		}
+		template.Items = items
+		return tx.Create(&template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Items").Preload("Items.Category").First(&template, template.ID)
+	c.JSON(http.StatusOK, template)
+}
+
+// --- Handler untuk Mengupdate Template Budget (item diganti seluruhnya) ---
+func UpdateBudgetTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input BudgetTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var template model.BudgetTemplate
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget template not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		items, err := buildTemplateItems(tx, currentUser, input)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return errors.New("template must contain at least one item")
+		}
+		if err := tx.Where("budget_template_id = ?", template.ID).Delete(&model.BudgetTemplateItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].BudgetTemplateID = template.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		template.Name = input.Name
+		return tx.Save(&template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	database.DB.Preload("Items").Preload("Items.Category").First(&template, template.ID)
+	c.JSON(http.StatusOK, template)
+}
+
+// --- Handler untuk Menghapus Template Budget ---
+func DeleteBudgetTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var template model.BudgetTemplate
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget template not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("budget_template_id = ?", template.ID).Delete(&model.BudgetTemplateItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&template).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete budget template"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget template deleted successfully"})
+}
+
+// --- Handler untuk Menerapkan Template ke satu periode atau rentang periode ---
+func ApplyBudgetTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input ApplyBudgetTemplateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var template model.BudgetTemplate
+	if err := database.DB.Preload("Items").Where("id = ? AND user_id = ?", id, currentUser.ID).First(&template).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Budget template not found"})
+		return
+	}
+
+	start, err := normalizePeriodKey(currentUser, input.StartYear, input.StartMonth, input.StartWeek)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start period: " + err.Error()})
+		return
+	}
+	// Jika akhir rentang tidak diisi, template hanya diterapkan ke periode awal
+	end := start
+	if input.EndYear != 0 {
+		end, err = normalizePeriodKey(currentUser, input.EndYear, input.EndMonth, input.EndWeek)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end period: " + err.Error()})
+			return
+		}
+	}
+	startDate, _ := periodRange(currentUser, start)
+	endDate, _ := periodRange(currentUser, end)
+	if endDate.Before(startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "End period must not be before start period"})
+		return
+	}
+
+	scale := 1.0
+	if input.Percentage != nil {
+		scale = *input.Percentage / 100
+	}
+
+	var budgetsToUpsert []model.Budget
+	periods := 0
+	for key := start; ; key = nextPeriod(currentUser, key) {
+		periods++
+		if periods > maxTemplateApplyPeriods {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Range is too long, maximum is " + strconv.Itoa(maxTemplateApplyPeriods) + " periods"})
+			return
+		}
+		for _, item := range template.Items {
+			budgetsToUpsert = append(budgetsToUpsert, newBudget(currentUser.ID, item.CategoryID, roundMoney(item.Amount*scale), key))
+		}
+		if key == end {
+			break
+		}
+	}
+
+	if err := upsertBudgets(database.DB, budgetsToUpsert); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply budget template"})
+		return
+	}
+	publishEvent(currentUser.ID, webhook.EventBudgetUpdated, gin.H{"budgets": budgetsToUpsert})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Budget template applied successfully", "periods": periods, "budgets": len(budgetsToUpsert)})
+}
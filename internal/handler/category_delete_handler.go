@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ringkasan data yang ikut terdampak jika kategori/sub-kategori dihapus
+type CategoryDeletePreview struct {
+	SubCategoryCount  int64 `json:"sub_category_count"`
+	TransactionCount  int64 `json:"transaction_count"`
+	BudgetCount       int64 `json:"budget_count"`
+	TemplateItemCount int64 `json:"budget_template_item_count"`
+}
+
+func (p CategoryDeletePreview) hasDependents() bool {
+	return p.TransactionCount > 0 || p.BudgetCount > 0 || p.TemplateItemCount > 0
+}
+
+// lockCategoryRows mengunci kategori dan sub-kategorinya sampai transaksi database selesai, sehingga
+// transaksi/budget baru yang merujuknya menunggu sampai penghapusan selesai
+func lockCategoryRows(tx *gorm.DB, categoryID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&model.Category{}, categoryID).Error; err != nil {
+		return err
+	}
+	var subCategories []model.SubCategory
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("category_id = ?", categoryID).Find(&subCategories).Error
+}
+
+func categoryDeletePreview(db *gorm.DB, categoryID uint) (CategoryDeletePreview, error) {
+	var preview CategoryDeletePreview
+	subCategoryIDs := db.Model(&model.SubCategory{}).Select("id").Where("category_id = ?", categoryID)
+	if err := db.Model(&model.SubCategory{}).Where("category_id = ?", categoryID).Count(&preview.SubCategoryCount).Error; err != nil {
+		return preview, err
+	}
+	if err := db.Model(&model.Transaction{}).Where("sub_category_id IN (?)", subCategoryIDs).Count(&preview.TransactionCount).Error; err != nil {
+		return preview, err
+	}
+	if err := db.Model(&model.Budget{}).Where("category_id = ?", categoryID).Count(&preview.BudgetCount).Error; err != nil {
+		return preview, err
+	}
+	if err := db.Model(&model.BudgetTemplateItem{}).Where("category_id = ?", categoryID).Count(&preview.TemplateItemCount).Error; err != nil {
+		return preview, err
+	}
+	return preview, nil
+}
+
+// findTargetSubCategory mencari sub-kategori tujuan pemindahan milik user (beserta kategorinya)
+func findTargetSubCategory(tx *gorm.DB, userID uint, param string) (model.SubCategory, error) {
+	var target model.SubCategory
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		return target, errors.New("target_sub_category_id is required for strategy=reassign")
+	}
+	if err := tx.Preload("Category").Where("id = ? AND user_id = ?", id, userID).First(&target).Error; err != nil {
+		return target, errors.New("target sub-category not found")
+	}
+	return target, nil
+}
+
+// findTargetCategory mencari kategori tujuan pemindahan milik user
+func findTargetCategory(tx *gorm.DB, userID uint, param string) (model.Category, error) {
+	var target model.Category
+	id, err := strconv.Atoi(param)
+	if err != nil {
+		return target, errors.New("target_category_id is required for strategy=reassign")
+	}
+	if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&target).Error; err != nil {
+		return target, errors.New("target category not found")
+	}
+	return target, nil
+}
+
+// moveBudgets memindahkan budget (dan item template budget) dari satu kategori ke kategori lain.
+// Jika kategori tujuan sudah punya budget di periode yang sama, nominalnya dijumlahkan.
+func moveBudgets(tx *gorm.DB, fromCategoryID, toCategoryID uint) error {
+	var budgets []model.Budget
+	if err := tx.Where("category_id = ?", fromCategoryID).Find(&budgets).Error; err != nil {
+		return err
+	}
+	for _, budget := range budgets {
+		var existing model.Budget
+		err := tx.Where("user_id = ? AND category_id = ? AND year = ? AND month = ? AND week = ?",
+			budget.UserID, toCategoryID, budget.Year, budget.Month, budget.Week).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Model(&budget).Update("category_id", toCategoryID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Update("amount", roundMoney(existing.Amount+budget.Amount)).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&budget).Error; err != nil {
+			return err
+		}
+	}
+
+	var items []model.BudgetTemplateItem
+	if err := tx.Where("category_id = ?", fromCategoryID).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		var existing model.BudgetTemplateItem
+		err := tx.Where("budget_template_id = ? AND category_id = ?", item.BudgetTemplateID, toCategoryID).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Model(&item).Update("category_id", toCategoryID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&existing).Update("amount", roundMoney(existing.Amount+item.Amount)).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCategoryWithStrategy menghapus kategori beserta sub-kategorinya. Transaksi dan budget
+// dipindahkan (reassign) atau ikut dihapus (cascade) dalam satu transaksi database. Transaksi
+// yang terdampak (isi sebelum perubahan) dan kategori tujuan budget dikembalikan untuk event.
+func deleteCategoryWithStrategy(tx *gorm.DB, category model.Category, strategy, targetCategoryParam, targetSubCategoryParam string) ([]model.Transaction, uint, error) {
+	var subCategoryIDs []uint
+	if err := tx.Model(&model.SubCategory{}).Where("category_id = ?", category.ID).Pluck("id", &subCategoryIDs).Error; err != nil {
+		return nil, 0, err
+	}
+	var transactions []model.Transaction
+	if len(subCategoryIDs) > 0 {
+		if err := tx.Where("sub_category_id IN ?", subCategoryIDs).Find(&transactions).Error; err != nil {
+			return nil, 0, err
+		}
+	}
+	targetCategoryID := uint(0)
+
+	switch strategy {
+	case "", "cascade":
+		if err := deleteTransactions(tx, transactions); err != nil {
+			return nil, 0, err
+		}
+		if err := tx.Where("category_id = ?", category.ID).Delete(&model.Budget{}).Error; err != nil {
+			return nil, 0, err
+		}
+		if err := tx.Where("category_id = ?", category.ID).Delete(&model.BudgetTemplateItem{}).Error; err != nil {
+			return nil, 0, err
+		}
+	case "reassign":
+		if len(transactions) > 0 || targetSubCategoryParam != "" {
+			targetSubCategory, err := findTargetSubCategory(tx, category.UserID, targetSubCategoryParam)
+			if err != nil {
+				return nil, 0, err
+			}
+			if targetSubCategory.CategoryID == category.ID {
+				return nil, 0, errors.New("target sub-category belongs to the deleted category")
+			}
+			if targetSubCategory.Category.Type != category.Type {
+				return nil, 0, errors.New("target sub-category must have the same type as the deleted category")
+			}
+			if len(subCategoryIDs) > 0 {
+				if err := tx.Model(&model.Transaction{}).Where("sub_category_id IN ?", subCategoryIDs).
+					Update("sub_category_id", targetSubCategory.ID).Error; err != nil {
+					return nil, 0, err
+				}
+			}
+			targetCategoryID = targetSubCategory.CategoryID
+		}
+		if targetCategoryParam != "" {
+			targetCategory, err := findTargetCategory(tx, category.UserID, targetCategoryParam)
+			if err != nil {
+				return nil, 0, err
+			}
+			if targetCategory.ID == category.ID {
+				return nil, 0, errors.New("target category must be different from the deleted category")
+			}
+			if targetCategory.Type != category.Type {
+				return nil, 0, errors.New("target category must have the same type as the deleted category")
+			}
+			targetCategoryID = targetCategory.ID
+		}
+		if targetCategoryID == 0 {
+			preview, err := categoryDeletePreview(tx, category.ID)
+			if err != nil {
+				return nil, 0, err
+			}
+			if preview.BudgetCount > 0 || preview.TemplateItemCount > 0 {
+				return nil, 0, errors.New("target_category_id or target_sub_category_id is required for strategy=reassign")
+			}
+		} else if err := moveBudgets(tx, category.ID, targetCategoryID); err != nil {
+			return nil, 0, err
+		}
+	default:
+		return nil, 0, errors.New("strategy must be reassign or cascade")
+	}
+
+	if err := replacePayeeDefaults(tx, "default_sub_category_id", subCategoryIDs, nil); err != nil {
+		return nil, 0, err
+	}
+	if err := replaceRecurringReferences(tx, "sub_category_id", subCategoryIDs, nil); err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Where("category_id = ?", category.ID).Delete(&model.SubCategory{}).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Delete(&category).Error; err != nil {
+		return nil, 0, err
+	}
+	return transactions, targetCategoryID, nil
+}
+
+// publishCategoryDeleteEvents mengirim event untuk transaksi yang ikut dihapus (cascade) atau
+// dipindahkan ke sub-kategori lain (reassign) saat kategori/sub-kategori dihapus
+func publishCategoryDeleteEvents(userID uint, strategy string, transactions []model.Transaction) {
+	if strategy == "reassign" {
+		publishTransactionsUpdated(database.DB, userID, transactions)
+		return
+	}
+	publishTransactionsDeleted(database.DB, userID, transactions)
+}
+
+// --- Handler untuk Pratinjau Dampak Penghapusan Kategori ---
+func GetCategoryDeletePreview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var category model.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	preview, err := categoryDeletePreview(database.DB, category.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build delete preview"})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+// --- Handler untuk Pratinjau Dampak Penghapusan Sub-Kategori ---
+func GetSubCategoryDeletePreview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var subCategory model.SubCategory
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&subCategory).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sub-category not found"})
+		return
+	}
+
+	var preview CategoryDeletePreview
+	if err := database.DB.Model(&model.Transaction{}).Where("sub_category_id = ?", subCategory.ID).Count(&preview.TransactionCount).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build delete preview"})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
@@ -1,22 +1,33 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 
 type CategoryInput struct {
-	Name string `json:"name" binding:"required"`
-	Type string `json:"type" binding:"required"` // "expense" or "income"
+	Name      string  `json:"name" binding:"required"`
+	Type      string  `json:"type" binding:"required,oneof=expense income"`
+	Icon      *string `json:"icon" binding:"omitempty,max=50"`
+	Color     *string `json:"color" binding:"omitempty,hexcolor,max=7"`
+	SortOrder *int    `json:"sort_order"`
+	Hidden    *bool   `json:"hidden"`
 }
 
 type SubCategoryInput struct {
-	Name string `json:"name" binding:"required"`
+	Name      string  `json:"name" binding:"required"`
+	Icon      *string `json:"icon" binding:"omitempty,max=50"`
+	Color     *string `json:"color" binding:"omitempty,hexcolor,max=7"`
+	SortOrder *int    `json:"sort_order"`
+	Hidden    *bool   `json:"hidden"`
 }
 
 // --- HANDLER UNTUK KATEGORI ---
@@ -33,6 +44,11 @@ func CreateCategory(c *gin.Context) {
 		Name:   input.Name,
 		Type:   input.Type,
 	}
+	// Kategori baru ditaruh di urutan paling akhir kecuali sort_order diisi
+	if input.SortOrder == nil {
+		category.SortOrder = nextSortOrder(database.DB.Model(&model.Category{}).Where("user_id = ?", currentUser.ID))
+	}
+	applyCategoryPresentation(&category, input)
 	if err := database.DB.Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
 		return
@@ -47,14 +63,18 @@ func CreateCategory(c *gin.Context) {
 func GetCategories(c *gin.Context) {
 	currentUser := c.MustGet("currentUser").(model.User)
 	categoryType := c.Query("type")
+	includeHidden := c.Query("include_hidden") == "true"
 	
 	// <-- PERUBAHAN UTAMA: Tambahkan .Preload("SubCategories")
 	// Ini akan mengambil semua sub-kategori yang terkait dengan setiap kategori.
-	query := database.DB.Preload("SubCategories").Where("user_id = ?", currentUser.ID)
+	query := database.DB.Preload("SubCategories", func(db *gorm.DB) *gorm.DB {
+		return visibleCategories(db, includeHidden)
+	}).Where("user_id = ?", currentUser.ID)
 	
 	if categoryType != "" {
 		query = query.Where("type = ?", categoryType)
 	}
+	query = visibleCategories(query, includeHidden)
 
 	var categories []model.Category
 	if err := query.Find(&categories).Error; err != nil {
@@ -86,8 +106,22 @@ func UpdateCategory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Tipe tidak boleh diganti jika sub-kategorinya sudah dipakai transaksi,
+	// karena transaksi lama akan jadi tidak konsisten dengan tipe kategorinya.
+	if input.Type != category.Type {
+		preview, err := categoryDeletePreview(database.DB, category.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check category usage"})
+			return
+		}
+		if preview.TransactionCount > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Category type cannot be changed while its sub-categories are used by transactions", "code": ErrCodeCategoryTypeInUse})
+			return
+		}
+	}
 	category.Name = input.Name
 	category.Type = input.Type
+	applyCategoryPresentation(&category, input)
 	database.DB.Save(&category)
 	
     // <-- PERUBAHAN: Muat relasi User sebelum mengirim respons
@@ -112,7 +146,40 @@ func DeleteCategory(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this category"})
 		return
 	}
-	database.DB.Delete(&category)
+
+	strategy := c.Query("strategy")
+	var preview CategoryDeletePreview
+	var transactions []model.Transaction
+	var budgetCategoryID uint
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryRows(tx, category.ID); err != nil {
+			return err
+		}
+		// Kategori yang masih dipakai transaksi/budget hanya boleh dihapus dengan strategi eksplisit
+		var err error
+		preview, err = categoryDeletePreview(tx, category.ID)
+		if err != nil {
+			return err
+		}
+		if strategy == "" && preview.hasDependents() {
+			return errDeleteStrategyRequired
+		}
+		transactions, budgetCategoryID, err = deleteCategoryWithStrategy(tx, category, strategy, c.Query("target_category_id"), c.Query("target_sub_category_id"))
+		return err
+	})
+	if errors.Is(err, errDeleteStrategyRequired) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "Category is in use, use strategy=reassign with target_sub_category_id/target_category_id or strategy=cascade",
+			"preview": preview,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	publishCategoryDeleteEvents(currentUser.ID, strategy, transactions)
+	publishCategoryBudgets(database.DB, currentUser.ID, budgetCategoryID)
 	c.JSON(http.StatusOK, gin.H{"message": "Category and its sub-categories deleted successfully"})
 }
 
@@ -141,6 +208,10 @@ func CreateSubCategory(c *gin.Context) {
 		CategoryID: uint(categoryID),
 		Name:       input.Name,
 	}
+	if input.SortOrder == nil {
+		subCategory.SortOrder = nextSortOrder(database.DB.Model(&model.SubCategory{}).Where("category_id = ?", category.ID))
+	}
+	applySubCategoryPresentation(&subCategory, input)
 	if err := database.DB.Create(&subCategory).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sub-category"})
 		return
@@ -162,7 +233,8 @@ func GetSubCategoriesForCategory(c *gin.Context) {
 	var subCategories []model.SubCategory
 	
     // <-- PERUBAHAN: Tambahkan .Preload untuk User dan Category
-	if err := database.DB.Preload("User").Preload("Category").Where("category_id = ? AND user_id = ?", categoryID, currentUser.ID).Find(&subCategories).Error; err != nil {
+	query := database.DB.Preload("User").Preload("Category").Where("category_id = ? AND user_id = ?", categoryID, currentUser.ID)
+	if err := visibleCategories(query, c.Query("include_hidden") == "true").Find(&subCategories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sub-categories"})
 		return
 	}
@@ -192,6 +264,7 @@ func UpdateSubCategory(c *gin.Context) {
 		return
 	}
 	subCategory.Name = input.Name
+	applySubCategoryPresentation(&subCategory, input)
 	database.DB.Save(&subCategory)
 	
     // <-- PERUBAHAN: Muat relasi User dan Category sebelum mengirim respons
@@ -216,7 +289,72 @@ func DeleteSubCategory(c *gin.Context) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to delete this sub-category"})
 		return
 	}
-	database.DB.Delete(&subCategory)
+
+	strategy := c.Query("strategy")
+	var transactions []model.Transaction
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci sub-kategori agar transaksi baru tidak bisa masuk di antara pengecekan dan penghapusan
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subCategory, subCategory.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("sub_category_id = ?", subCategory.ID).Find(&transactions).Error; err != nil {
+			return err
+		}
+		if strategy == "" && len(transactions) > 0 {
+			return errDeleteStrategyRequired
+		}
+		switch strategy {
+		case "", "cascade":
+			if err := deleteTransactions(tx, transactions); err != nil {
+				return err
+			}
+			if err := replacePayeeDefaults(tx, "default_sub_category_id", []uint{subCategory.ID}, nil); err != nil {
+				return err
+			}
+			if err := replaceRecurringReferences(tx, "sub_category_id", []uint{subCategory.ID}, nil); err != nil {
+				return err
+			}
+		case "reassign":
+			target, err := findTargetSubCategory(tx, currentUser.ID, c.Query("target_sub_category_id"))
+			if err != nil {
+				return err
+			}
+			if target.ID == subCategory.ID {
+				return errors.New("target sub-category must be different from the deleted sub-category")
+			}
+			var category model.Category
+			if err := tx.First(&category, subCategory.CategoryID).Error; err != nil {
+				return err
+			}
+			if target.Category.Type != category.Type {
+				return errors.New("target sub-category must have the same type as the deleted sub-category")
+			}
+			if err := tx.Model(&model.Transaction{}).Where("sub_category_id = ?", subCategory.ID).Update("sub_category_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := replacePayeeDefaults(tx, "default_sub_category_id", []uint{subCategory.ID}, &target.ID); err != nil {
+				return err
+			}
+			if err := replaceRecurringReferences(tx, "sub_category_id", []uint{subCategory.ID}, &target.ID); err != nil {
+				return err
+			}
+		default:
+			return errors.New("strategy must be reassign or cascade")
+		}
+		return tx.Delete(&subCategory).Error
+	})
+	if errors.Is(err, errDeleteStrategyRequired) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":             "Sub-category is in use, use strategy=reassign with target_sub_category_id or strategy=cascade",
+			"transaction_count": len(transactions),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	publishCategoryDeleteEvents(currentUser.ID, strategy, transactions)
 	c.JSON(http.StatusOK, gin.H{"message": "Sub-category deleted successfully"})
 }
 
@@ -231,10 +369,10 @@ func GetAllSubCategoriesForCategory(c *gin.Context) {
     // Mengambil data user yang sedang login
 	currentUser := c.MustGet("currentUser").(model.User)
 
-    // Mencari semua sub-kategori di database
+    // Mencari semua sub-kategori di database (termasuk yang disembunyikan)
     // yang category_id-nya cocok DAN user_id-nya cocok
 	var subCategories []model.SubCategory
-	if err := database.DB.Where("category_id = ? AND user_id = ?", categoryID, currentUser.ID).Find(&subCategories).Error; err != nil {
+	if err := database.DB.Where("category_id = ? AND user_id = ?", categoryID, currentUser.ID).Order("sort_order asc, id asc").Find(&subCategories).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sub-categories"})
 		return
 	}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type MergeCategoryInput struct {
+	TargetCategoryID uint `json:"target_category_id" binding:"required"`
+}
+
+type MergeSubCategoryInput struct {
+	TargetSubCategoryID uint `json:"target_sub_category_id" binding:"required"`
+}
+
+// mergeSubCategory memindahkan semua transaksi sub-kategori sumber ke target lalu menghapus sumber.
+// Transaksi yang dipindahkan dikembalikan (isi sebelum perubahan) untuk event transaction.updated.
+func mergeSubCategory(tx *gorm.DB, source, target model.SubCategory) ([]model.Transaction, error) {
+	var moved []model.Transaction
+	if err := tx.Where("sub_category_id = ?", source.ID).Find(&moved).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&model.Transaction{}).Where("sub_category_id = ?", source.ID).Update("sub_category_id", target.ID).Error; err != nil {
+		return nil, err
+	}
+	if err := replacePayeeDefaults(tx, "default_sub_category_id", []uint{source.ID}, &target.ID); err != nil {
+		return nil, err
+	}
+	if err := replaceRecurringReferences(tx, "sub_category_id", []uint{source.ID}, &target.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(&source).Error; err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// --- Handler untuk Menggabungkan Kategori ke Kategori Lain ---
+// Sub-kategori dipindahkan ke kategori target (yang bernama sama digabung),
+// budget dipindahkan dan dijumlahkan jika bentrok, lalu kategori sumber dihapus.
+func MergeCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input MergeCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var source model.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&source).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+	var target model.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", input.TargetCategoryID, currentUser.ID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target category not found"})
+		return
+	}
+	if source.ID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a category into itself"})
+		return
+	}
+	if source.Type != target.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Categories must have the same type to be merged"})
+		return
+	}
+
+	var moved []model.Transaction
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var sourceSubCategories, targetSubCategories []model.SubCategory
+		if err := tx.Where("category_id = ?", source.ID).Find(&sourceSubCategories).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", target.ID).Find(&targetSubCategories).Error; err != nil {
+			return err
+		}
+		targetByName := make(map[string]model.SubCategory)
+		for _, subCategory := range targetSubCategories {
+			targetByName[strings.ToLower(strings.TrimSpace(subCategory.Name))] = subCategory
+		}
+
+		for _, subCategory := range sourceSubCategories {
+			if existing, ok := targetByName[strings.ToLower(strings.TrimSpace(subCategory.Name))]; ok {
+				transactions, err := mergeSubCategory(tx, subCategory, existing)
+				if err != nil {
+					return err
+				}
+				moved = append(moved, transactions...)
+				continue
+			}
+			if err := tx.Model(&subCategory).Update("category_id", target.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := moveBudgets(tx, source.ID, target.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&source).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge categories"})
+		return
+	}
+	publishTransactionsUpdated(database.DB, currentUser.ID, moved)
+	publishCategoryBudgets(database.DB, currentUser.ID, target.ID)
+
+	database.DB.Preload("SubCategories").First(&target, target.ID)
+	c.JSON(http.StatusOK, target)
+}
+
+// --- Handler untuk Menggabungkan Sub-Kategori ke Sub-Kategori Lain ---
+func MergeSubCategory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input MergeSubCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var source model.SubCategory
+	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", id, currentUser.ID).First(&source).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sub-category not found"})
+		return
+	}
+	var target model.SubCategory
+	if err := database.DB.Preload("Category").Where("id = ? AND user_id = ?", input.TargetSubCategoryID, currentUser.ID).First(&target).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target sub-category not found"})
+		return
+	}
+	if source.ID == target.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot merge a sub-category into itself"})
+		return
+	}
+	if source.Category.Type != target.Category.Type {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Sub-categories must belong to categories of the same type to be merged"})
+		return
+	}
+
+	var moved []model.Transaction
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		moved, err = mergeSubCategory(tx, source, target)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge sub-categories"})
+		return
+	}
+	publishTransactionsUpdated(database.DB, currentUser.ID, moved)
+
+	c.JSON(http.StatusOK, target)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReorderInput berisi ID dalam urutan tampilan yang diinginkan
+type ReorderInput struct {
+	IDs []uint `json:"ids" binding:"required,min=1"`
+}
+
+// visibleCategories mengurutkan kategori/sub-kategori sesuai sort_order dan
+// menyembunyikan yang hidden kecuali diminta.
+func visibleCategories(db *gorm.DB, includeHidden bool) *gorm.DB {
+	if !includeHidden {
+		db = db.Where("hidden = ?", false)
+	}
+	return db.Order("sort_order asc, id asc")
+}
+
+// nextSortOrder mengembalikan sort_order setelah item terakhir pada query
+func nextSortOrder(query *gorm.DB) int {
+	var maxOrder int
+	query.Select("COALESCE(MAX(sort_order), -1)").Scan(&maxOrder)
+	return maxOrder + 1
+}
+
+func applyCategoryPresentation(category *model.Category, input CategoryInput) {
+	if input.Icon != nil {
+		category.Icon = *input.Icon
+	}
+	if input.Color != nil {
+		category.Color = *input.Color
+	}
+	if input.SortOrder != nil {
+		category.SortOrder = *input.SortOrder
+	}
+	if input.Hidden != nil {
+		category.Hidden = *input.Hidden
+	}
+}
+
+func applySubCategoryPresentation(subCategory *model.SubCategory, input SubCategoryInput) {
+	if input.Icon != nil {
+		subCategory.Icon = *input.Icon
+	}
+	if input.Color != nil {
+		subCategory.Color = *input.Color
+	}
+	if input.SortOrder != nil {
+		subCategory.SortOrder = *input.SortOrder
+	}
+	if input.Hidden != nil {
+		subCategory.Hidden = *input.Hidden
+	}
+}
+
+// reorder menyimpan posisi baru sesuai urutan ids. Semua id harus ada di scope;
+// item dalam scope yang tidak disebut ditaruh setelahnya dengan urutan lama.
+func reorder(scope *gorm.DB, table interface{}, ids []uint) (bool, error) {
+	var existing []uint
+	if err := scope.Session(&gorm.Session{}).Model(table).Order("sort_order asc, id asc").Pluck("id", &existing).Error; err != nil {
+		return false, err
+	}
+	known := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if !known[id] || seen[id] {
+			return false, nil
+		}
+		seen[id] = true
+	}
+
+	ordered := append([]uint{}, ids...)
+	for _, id := range existing {
+		if !seen[id] {
+			ordered = append(ordered, id)
+		}
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range ordered {
+			if err := tx.Model(table).Where("id = ?", id).Update("sort_order", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return true, err
+}
+
+// --- Handler untuk Mengurutkan Ulang Kategori ---
+func ReorderCategories(c *gin.Context) {
+	var input ReorderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	ok, err := reorder(database.DB.Where("user_id = ?", currentUser.ID), &model.Category{}, input.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder categories"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be unique categories owned by the user"})
+		return
+	}
+
+	var categories []model.Category
+	database.DB.Preload("SubCategories", func(db *gorm.DB) *gorm.DB {
+		return visibleCategories(db, true)
+	}).Where("user_id = ?", currentUser.ID).Order("sort_order asc, id asc").Find(&categories)
+	c.JSON(http.StatusOK, categories)
+}
+
+// --- Handler untuk Mengurutkan Ulang Sub-Kategori dalam Satu Kategori ---
+func ReorderSubCategories(c *gin.Context) {
+	categoryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Category ID"})
+		return
+	}
+	var input ReorderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var category model.Category
+	if err := database.DB.Where("id = ? AND user_id = ?", categoryID, currentUser.ID).First(&category).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	ok, err := reorder(database.DB.Where("category_id = ?", category.ID), &model.SubCategory{}, input.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reorder sub-categories"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids must be unique sub-categories of this category"})
+		return
+	}
+
+	var subCategories []model.SubCategory
+	database.DB.Where("category_id = ?", category.ID).Order("sort_order asc, id asc").Find(&subCategories)
+	c.JSON(http.StatusOK, subCategories)
+}
@@ -0,0 +1,286 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CreditCardDetailInput struct {
+	StatementDay          int      `json:"statement_day" binding:"required,min=1,max=31"`
+	DueDay                int      `json:"due_day" binding:"required,min=1,max=31"`
+	MinimumPaymentPercent *float64 `json:"minimum_payment_percent" binding:"omitempty,gte=0,lte=100"`
+	MinimumPaymentAmount  *float64 `json:"minimum_payment_amount" binding:"omitempty,gte=0"`
+	InterestRate          *float64 `json:"interest_rate" binding:"omitempty,gte=0"`
+}
+
+type PayCreditCardInput struct {
+	FromAccountID uint       `json:"from_account_id" binding:"required"`
+	Option        string     `json:"option" binding:"omitempty,oneof=statement minimum full custom"` // default custom jika amount diisi, selain itu statement
+	Amount        *float64   `json:"amount" binding:"omitempty,gt=0"`
+	Date          *time.Time `json:"date"`
+	Notes         string     `json:"notes"`
+}
+
+type CreditCardStatement struct {
+	Detail                  model.CreditCardDetail `json:"detail"`
+	CurrentBalance          float64                `json:"current_balance"` // total utang saat ini
+	StatementStart          time.Time              `json:"statement_start"`
+	StatementClosingDate    time.Time              `json:"statement_closing_date"`
+	DueDate                 time.Time              `json:"due_date"`
+	StatementBalance        float64                `json:"statement_balance"`
+	MinimumPayment          float64                `json:"minimum_payment"`
+	PaymentsSinceStatement  float64                `json:"payments_since_statement"`
+	RemainingStatementDue   float64                `json:"remaining_statement_due"`
+	RemainingMinimumPayment float64                `json:"remaining_minimum_payment"`
+	UnbilledAmount          float64                `json:"unbilled_amount"` // transaksi setelah tanggal tutup tagihan
+	NextClosingDate         time.Time              `json:"next_closing_date"`
+}
+
+// dayInMonth membuat tanggal pada hari tertentu, disesuaikan untuk bulan yang lebih pendek
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// lastStatementClosing mengembalikan tanggal tutup tagihan terakhir sebelum hari ini.
+// Tagihan yang tutup hari ini dianggap belum tertutup sampai hari berakhir.
+func lastStatementClosing(statementDay int, today time.Time) time.Time {
+	closing := dayInMonth(today.Year(), today.Month(), statementDay)
+	if !closing.Before(today) {
+		closing = dayInMonth(today.Year(), today.Month()-1, statementDay)
+	}
+	return closing
+}
+
+// statementDueDate adalah DueDay pertama setelah tanggal tutup tagihan
+func statementDueDate(closing time.Time, dueDay int) time.Time {
+	due := dayInMonth(closing.Year(), closing.Month(), dueDay)
+	if !due.After(closing) {
+		due = dayInMonth(closing.Year(), closing.Month()+1, dueDay)
+	}
+	return due
+}
+
+// creditCardMinimumPayment menghitung pembayaran minimum dari saldo tagihan
+func creditCardMinimumPayment(detail model.CreditCardDetail, statementBalance float64) float64 {
+	if statementBalance <= 0 {
+		return 0
+	}
+	minimum := math.Max(statementBalance*detail.MinimumPaymentPercent/100, detail.MinimumPaymentAmount)
+	return roundMoney(math.Min(minimum, statementBalance))
+}
+
+// buildCreditCardStatement menghitung tagihan terakhir, jatuh tempo dan transaksi yang belum ditagihkan
+func buildCreditCardStatement(db *gorm.DB, account model.Account, detail model.CreditCardDetail, now time.Time) (CreditCardStatement, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	closing := lastStatementClosing(detail.StatementDay, today)
+	previousClosing := dayInMonth(closing.Year(), closing.Month()-1, detail.StatementDay)
+	statement := CreditCardStatement{
+		Detail:               detail,
+		CurrentBalance:       roundMoney(math.Max(-account.Balance, 0)),
+		StatementStart:       previousClosing.AddDate(0, 0, 1),
+		StatementClosingDate: closing,
+		DueDate:              statementDueDate(closing, detail.DueDay),
+		NextClosingDate:      dayInMonth(closing.Year(), closing.Month()+1, detail.StatementDay),
+	}
+
+	balanceAtClosing, err := accountBalanceAt(db, account, closing)
+	if err != nil {
+		return statement, err
+	}
+	statement.StatementBalance = roundMoney(math.Max(-balanceAtClosing, 0))
+	statement.MinimumPayment = creditCardMinimumPayment(detail, statement.StatementBalance)
+
+	var recent []model.Transaction
+	if err := accountTransactions(db, account.ID).Where("transaction_date >= ?", closing.AddDate(0, 0, 1)).Find(&recent).Error; err != nil {
+		return statement, err
+	}
+	for _, transaction := range recent {
+		delta := ledger.Delta(transaction, account.ID)
+		if delta > 0 {
+			statement.PaymentsSinceStatement += delta
+		} else {
+			statement.UnbilledAmount -= delta
+		}
+	}
+	statement.PaymentsSinceStatement = roundMoney(statement.PaymentsSinceStatement)
+	statement.UnbilledAmount = roundMoney(statement.UnbilledAmount)
+	statement.RemainingStatementDue = roundMoney(math.Max(statement.StatementBalance-statement.PaymentsSinceStatement, 0))
+	statement.RemainingMinimumPayment = roundMoney(math.Max(statement.MinimumPayment-statement.PaymentsSinceStatement, 0))
+	return statement, nil
+}
+
+// findCreditCardAccount mengambil akun kartu kredit milik user
+func findCreditCardAccount(c *gin.Context) (model.Account, bool) {
+	var account model.Account
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return account, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return account, false
+	}
+	if account.Type != model.AccountTypeCreditCard {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not a credit card account"})
+		return account, false
+	}
+	return account, true
+}
+
+func findCreditCardDetail(c *gin.Context, account model.Account) (model.CreditCardDetail, bool) {
+	var detail model.CreditCardDetail
+	if err := database.DB.Where("account_id = ?", account.ID).First(&detail).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Statement cycle has not been set for this credit card"})
+		return detail, false
+	}
+	return detail, true
+}
+
+// --- Handler untuk Mengatur Siklus Tagihan Kartu Kredit ---
+func SetCreditCardDetail(c *gin.Context) {
+	account, ok := findCreditCardAccount(c)
+	if !ok {
+		return
+	}
+	var input CreditCardDetailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	detail := model.CreditCardDetail{MinimumPaymentPercent: 5}
+	database.DB.Where("account_id = ?", account.ID).First(&detail)
+	detail.AccountID = account.ID
+	detail.StatementDay = input.StatementDay
+	detail.DueDay = input.DueDay
+	if input.MinimumPaymentPercent != nil {
+		detail.MinimumPaymentPercent = *input.MinimumPaymentPercent
+	}
+	if input.MinimumPaymentAmount != nil {
+		detail.MinimumPaymentAmount = *input.MinimumPaymentAmount
+	}
+	if input.InterestRate != nil {
+		detail.InterestRate = *input.InterestRate
+	}
+	if err := database.DB.Omit("Account").Save(&detail).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save credit card details"})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// --- Handler untuk Tagihan Kartu Kredit Saat Ini ---
+func GetCreditCardStatement(c *gin.Context) {
+	account, ok := findCreditCardAccount(c)
+	if !ok {
+		return
+	}
+	detail, ok := findCreditCardDetail(c, account)
+	if !ok {
+		return
+	}
+	statement, err := buildCreditCardStatement(database.DB, account, detail, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build credit card statement"})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
+
+// --- Handler untuk Membayar Kartu Kredit (transfer dari akun lain) ---
+// option: statement = sisa tagihan, minimum = sisa pembayaran minimum,
+// full = seluruh utang saat ini, custom = nominal amount.
+func PayCreditCard(c *gin.Context) {
+	account, ok := findCreditCardAccount(c)
+	if !ok {
+		return
+	}
+	var input PayCreditCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	option := input.Option
+	if option == "" {
+		option = "statement"
+		if input.Amount != nil {
+			option = "custom"
+		}
+	}
+
+	var amount float64
+	switch option {
+	case "custom":
+		if input.Amount == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount is required for option=custom"})
+			return
+		}
+		amount = *input.Amount
+	case "full":
+		amount = roundMoney(math.Max(-account.Balance, 0))
+	default:
+		detail, ok := findCreditCardDetail(c, account)
+		if !ok {
+			return
+		}
+		statement, err := buildCreditCardStatement(database.DB, account, detail, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build credit card statement"})
+			return
+		}
+		amount = statement.RemainingStatementDue
+		if option == "minimum" {
+			amount = statement.RemainingMinimumPayment
+		}
+	}
+	if amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to pay for option=" + option})
+		return
+	}
+
+	date := time.Now()
+	if input.Date != nil {
+		date = *input.Date
+	}
+	notes := input.Notes
+	if notes == "" {
+		notes = "Credit card payment: " + account.Name
+	}
+
+	var transaction model.Transaction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transaction, err = createTransaction(tx, account.UserID, TransactionInput{
+			AccountID:            input.FromAccountID,
+			Amount:               amount,
+			Type:                 model.TransactionTypeTransfer,
+			Notes:                notes,
+			TransactionDate:      date,
+			DestinationAccountID: &account.ID,
+		})
+		return err
+	})
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	database.DB.Preload("Account").First(&transaction, transaction.ID)
+	publishEvent(transaction.UserID, webhook.EventTransactionCreated, transaction)
+	c.JSON(http.StatusOK, transaction)
+}
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultLanguage = "en"
+
+// DefaultCategory adalah satu kategori bawaan beserta nama per bahasa ("en", "id", ...)
+type DefaultCategory struct {
+	Type          string              `json:"type"`
+	Icon          string              `json:"icon,omitempty"`
+	Names         map[string]string   `json:"names"`
+	SubCategories []map[string]string `json:"sub_categories"`
+}
+
+type DefaultCategoriesInput struct {
+	Mode     string `json:"mode" binding:"omitempty,oneof=apply reset"`
+	Language string `json:"language" binding:"omitempty,oneof=en id"`
+}
+
+// Daftar kategori bawaan. Bisa diganti dengan file JSON berformat sama
+// lewat environment variable DEFAULT_CATEGORIES_FILE.
+var builtinDefaultCategories = []DefaultCategory{
+	{Type: "expense", Icon: "restaurant", Names: map[string]string{"en": "Food & Drinks", "id": "Makanan & Minuman"}, SubCategories: []map[string]string{
+		{"en": "Groceries", "id": "Belanja Dapur"},
+		{"en": "Restaurants", "id": "Restoran"},
+		{"en": "Coffee & Snacks", "id": "Kopi & Camilan"},
+	}},
+	{Type: "expense", Icon: "directions_car", Names: map[string]string{"en": "Transportation", "id": "Transportasi"}, SubCategories: []map[string]string{
+		{"en": "Fuel", "id": "Bensin"},
+		{"en": "Public Transport", "id": "Transportasi Umum"},
+		{"en": "Parking & Tolls", "id": "Parkir & Tol"},
+		{"en": "Ride Hailing", "id": "Ojek Online"},
+	}},
+	{Type: "expense", Icon: "home", Names: map[string]string{"en": "Housing", "id": "Tempat Tinggal"}, SubCategories: []map[string]string{
+		{"en": "Rent", "id": "Sewa"},
+		{"en": "Electricity", "id": "Listrik"},
+		{"en": "Water", "id": "Air"},
+		{"en": "Internet", "id": "Internet"},
+	}},
+	{Type: "expense", Icon: "shopping_bag", Names: map[string]string{"en": "Shopping", "id": "Belanja"}, SubCategories: []map[string]string{
+		{"en": "Clothing", "id": "Pakaian"},
+		{"en": "Household", "id": "Kebutuhan Rumah Tangga"},
+		{"en": "Electronics", "id": "Elektronik"},
+	}},
+	{Type: "expense", Icon: "local_hospital", Names: map[string]string{"en": "Health", "id": "Kesehatan"}, SubCategories: []map[string]string{
+		{"en": "Medicine", "id": "Obat"},
+		{"en": "Doctor", "id": "Dokter"},
+		{"en": "Insurance", "id": "Asuransi"},
+	}},
+	{Type: "expense", Icon: "movie", Names: map[string]string{"en": "Entertainment", "id": "Hiburan"}, SubCategories: []map[string]string{
+		{"en": "Subscriptions", "id": "Langganan"},
+		{"en": "Movies & Events", "id": "Film & Acara"},
+		{"en": "Hobbies", "id": "Hobi"},
+	}},
+	{Type: "expense", Icon: "school", Names: map[string]string{"en": "Education", "id": "Pendidikan"}, SubCategories: []map[string]string{
+		{"en": "Tuition", "id": "Biaya Sekolah"},
+		{"en": "Books & Courses", "id": "Buku & Kursus"},
+	}},
+	{Type: "expense", Icon: "receipt", Names: map[string]string{"en": "Bills & Fees", "id": "Tagihan & Biaya"}, SubCategories: []map[string]string{
+		{"en": "Phone & Data", "id": "Pulsa & Paket Data"},
+		{"en": "Bank Fees", "id": "Biaya Bank"},
+		{"en": "Taxes", "id": "Pajak"},
+	}},
+	{Type: "expense", Icon: "more_horiz", Names: map[string]string{"en": "Others", "id": "Lainnya"}, SubCategories: []map[string]string{
+		{"en": "Gifts & Donations", "id": "Hadiah & Donasi"},
+		{"en": "Miscellaneous", "id": "Lain-lain"},
+	}},
+	{Type: "income", Icon: "payments", Names: map[string]string{"en": "Salary", "id": "Gaji"}, SubCategories: []map[string]string{
+		{"en": "Monthly Salary", "id": "Gaji Bulanan"},
+		{"en": "Bonus", "id": "Bonus"},
+	}},
+	{Type: "income", Icon: "storefront", Names: map[string]string{"en": "Business", "id": "Usaha"}, SubCategories: []map[string]string{
+		{"en": "Sales", "id": "Penjualan"},
+		{"en": "Freelance", "id": "Freelance"},
+	}},
+	{Type: "income", Icon: "trending_up", Names: map[string]string{"en": "Investment", "id": "Investasi"}, SubCategories: []map[string]string{
+		{"en": "Dividends", "id": "Dividen"},
+		{"en": "Interest", "id": "Bunga"},
+	}},
+	{Type: "income", Icon: "redeem", Names: map[string]string{"en": "Other Income", "id": "Pemasukan Lain"}, SubCategories: []map[string]string{
+		{"en": "Gifts", "id": "Hadiah"},
+		{"en": "Refunds", "id": "Pengembalian Dana"},
+	}},
+}
+
+var (
+	defaultCategoriesOnce sync.Once
+	defaultCategories     []DefaultCategory
+)
+
+// loadDefaultCategories membaca DEFAULT_CATEGORIES_FILE sekali saja; jika tidak ada
+// atau gagal dibaca, daftar bawaan yang dipakai.
+func loadDefaultCategories() []DefaultCategory {
+	defaultCategoriesOnce.Do(func() {
+		defaultCategories = builtinDefaultCategories
+		path := os.Getenv("DEFAULT_CATEGORIES_FILE")
+		if path == "" {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Println("Failed to read default categories file, using built-in defaults:", err)
+			return
+		}
+		var custom []DefaultCategory
+		if err := json.Unmarshal(data, &custom); err != nil {
+			log.Println("Failed to parse default categories file, using built-in defaults:", err)
+			return
+		}
+		defaultCategories = custom
+	})
+	return defaultCategories
+}
+
+// localizedName memilih nama sesuai bahasa, dengan bahasa Inggris sebagai cadangan
+func localizedName(names map[string]string, language string) string {
+	if name, ok := names[language]; ok && name != "" {
+		return name
+	}
+	return names[defaultLanguage]
+}
+
+// seedDefaultCategories menambahkan kategori & sub-kategori bawaan yang belum dimiliki user.
+// Pencocokan berdasarkan nama (tanpa membedakan huruf besar/kecil) dan tipe, jadi aman dipanggil ulang.
+func seedDefaultCategories(tx *gorm.DB, userID uint, language string) (int, error) {
+	created := 0
+	for _, defaultCategory := range loadDefaultCategories() {
+		name := localizedName(defaultCategory.Names, language)
+		if name == "" {
+			continue
+		}
+
+		var category model.Category
+		err := tx.Where("user_id = ? AND type = ? AND LOWER(name) = ?", userID, defaultCategory.Type, strings.ToLower(name)).First(&category).Error
+		if err != nil {
+			category = model.Category{UserID: userID, Name: name, Type: defaultCategory.Type, Icon: defaultCategory.Icon,
+				SortOrder: nextSortOrder(tx.Model(&model.Category{}).Where("user_id = ?", userID))}
+			if err := tx.Create(&category).Error; err != nil {
+				return created, err
+			}
+			created++
+		}
+
+		for _, subNames := range defaultCategory.SubCategories {
+			subName := localizedName(subNames, language)
+			if subName == "" {
+				continue
+			}
+			var count int64
+			if err := tx.Model(&model.SubCategory{}).Where("category_id = ? AND LOWER(name) = ?", category.ID, strings.ToLower(subName)).Count(&count).Error; err != nil {
+				return created, err
+			}
+			if count > 0 {
+				continue
+			}
+			subCategory := model.SubCategory{UserID: userID, CategoryID: category.ID, Name: subName,
+				SortOrder: nextSortOrder(tx.Model(&model.SubCategory{}).Where("category_id = ?", category.ID))}
+			if err := tx.Create(&subCategory).Error; err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// removeUnusedCategories menghapus kategori & sub-kategori user yang belum dipakai
+// transaksi, budget, template budget, default payee, maupun tagihan. Yang masih dipakai dibiarkan.
+func removeUnusedCategories(tx *gorm.DB, userID uint) (int, error) {
+	removed := 0
+	usedSubCategories := tx.Model(&model.Transaction{}).Select("sub_category_id").Where("user_id = ? AND sub_category_id IS NOT NULL", userID)
+	payeeSubCategories := tx.Model(&model.Payee{}).Select("default_sub_category_id").Where("user_id = ? AND default_sub_category_id IS NOT NULL", userID)
+	billSubCategories := tx.Model(&model.Bill{}).Select("sub_category_id").Where("user_id = ? AND sub_category_id IS NOT NULL", userID)
+	result := tx.Where("user_id = ? AND id NOT IN (?) AND id NOT IN (?) AND id NOT IN (?)", userID, usedSubCategories, payeeSubCategories, billSubCategories).Delete(&model.SubCategory{})
+	if result.Error != nil {
+		return removed, result.Error
+	}
+	removed += int(result.RowsAffected)
+
+	var categories []model.Category
+	if err := tx.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return removed, err
+	}
+	for _, category := range categories {
+		preview, err := categoryDeletePreview(tx, category.ID)
+		if err != nil {
+			return removed, err
+		}
+		if preview.SubCategoryCount > 0 || preview.hasDependents() {
+			continue
+		}
+		if err := tx.Delete(&category).Error; err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// --- Handler untuk Menerapkan Ulang atau Reset ke Kategori Bawaan ---
+// mode=apply (default) hanya menambahkan yang belum ada; mode=reset menghapus
+// kategori yang belum dipakai terlebih dahulu lalu menambahkan kategori bawaan.
+func ApplyDefaultCategories(c *gin.Context) {
+	var input DefaultCategoriesInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	language := input.Language
+	if language == "" {
+		language = currentUser.Language
+	}
+
+	removed, created := 0, 0
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if input.Mode == "reset" {
+			if removed, err = removeUnusedCategories(tx, currentUser.ID); err != nil {
+				return err
+			}
+		}
+		created, err = seedDefaultCategories(tx, currentUser.ID, language)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply default categories"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Default categories applied successfully", "created": created, "removed": removed})
+}
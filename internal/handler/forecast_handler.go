@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/forecast"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/recurring"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Batas proyeksi dan riwayat yang dipakai untuk rata-rata
+const (
+	defaultForecastDays      = 90
+	maxForecastDays          = 365
+	defaultForecastHistory   = 90
+	maxForecastHistory       = 730
+	forecastOverdueBillsDays = 30 // tagihan terlambat yang belum dibayar dianggap dibayar hari ini
+)
+
+type CategoryAverage struct {
+	CategoryID     uint    `json:"category_id"`
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	Total          float64 `json:"total"`
+	DailyAverage   float64 `json:"daily_average"`
+	MonthlyAverage float64 `json:"monthly_average"`
+}
+
+type ForecastResponse struct {
+	From             time.Time               `json:"from"`
+	To               time.Time               `json:"to"`
+	HistoryDays      int                     `json:"history_days"`
+	CategoryAverages []CategoryAverage       `json:"category_averages"`
+	Events           []forecast.Event        `json:"events"`
+	Accounts         []forecast.Projection   `json:"accounts"`
+	Overall          forecast.Projection     `json:"overall"`
+	NegativeDates    []forecast.NegativeDate `json:"negative_dates"`
+}
+
+// parseIntQuery membaca parameter angka opsional dengan batas min & max
+func parseIntQuery(c *gin.Context, name string, fallback, min, max int) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < min || parsed > max {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be a number between " + strconv.Itoa(min) + " and " + strconv.Itoa(max)})
+		return 0, false
+	}
+	return parsed, true
+}
+
+// scheduledTransactionEvents memisahkan transaksi bertanggal setelah hari ini: saldo saat ini
+// sudah memuatnya, jadi efeknya dikurangkan dari saldo awal dan dijadwalkan ulang sebagai kejadian.
+func scheduledTransactionEvents(db *gorm.DB, userID uint, today time.Time, balances map[uint]float64) ([]forecast.Event, error) {
+	var transactions []model.Transaction
+	if err := db.Where("user_id = ? AND transaction_date >= ?", userID, today.AddDate(0, 0, 1)).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	events := []forecast.Event{}
+	for _, transaction := range transactions {
+		ledger.Revert(balances, transaction)
+		accountIDs := []uint{transaction.AccountID}
+		if transaction.DestinationAccountID != nil && *transaction.DestinationAccountID != transaction.AccountID {
+			accountIDs = append(accountIDs, *transaction.DestinationAccountID)
+		}
+		for _, accountID := range accountIDs {
+			events = append(events, forecast.Event{
+				Date:      truncateDate(transaction.TransactionDate),
+				AccountID: &accountID,
+				Source:    forecast.SourceScheduledTransaction,
+				SourceID:  transaction.ID,
+				Name:      transaction.Notes,
+				Amount:    roundMoney(ledger.Delta(transaction, accountID)),
+			})
+		}
+	}
+	return events, nil
+}
+
+// billEvents menjadwalkan jatuh tempo tagihan aktif yang belum dibayar
+func billEvents(db *gorm.DB, userID uint, today, end time.Time) ([]forecast.Event, error) {
+	var bills []model.Bill
+	if err := db.Where("user_id = ? AND active = ?", userID, true).Find(&bills).Error; err != nil {
+		return nil, err
+	}
+	billIDs := make([]uint, 0, len(bills))
+	for _, bill := range bills {
+		billIDs = append(billIDs, bill.ID)
+	}
+	payments, err := billPaymentsByDate(db, billIDs)
+	if err != nil {
+		return nil, err
+	}
+	events := []forecast.Event{}
+	for _, bill := range bills {
+		for _, due := range billOccurrences(bill, today.AddDate(0, 0, -forecastOverdueBillsDays), end) {
+			if _, paid := payments[bill.ID][due.Format("2006-01-02")]; paid {
+				continue
+			}
+			events = append(events, forecast.Event{
+				Date:      due,
+				AccountID: bill.AccountID,
+				Source:    forecast.SourceBill,
+				SourceID:  bill.ID,
+				Name:      bill.Name,
+				Amount:    -bill.Amount,
+			})
+		}
+	}
+	return events, nil
+}
+
+// subscriptionEvents menjadwalkan langganan terkonfirmasi yang belum dijadikan tagihan
+func subscriptionEvents(subscriptions []model.DetectedSubscription, today, end time.Time) []forecast.Event {
+	events := []forecast.Event{}
+	for _, subscription := range subscriptions {
+		if subscription.BillID != nil {
+			continue
+		}
+		for date := subscription.NextExpectedDate; !date.After(end); date = recurring.NextDate(date, subscription.Frequency) {
+			if date.Before(today) {
+				continue
+			}
+			events = append(events, forecast.Event{
+				Date:      date,
+				AccountID: subscription.AccountID,
+				Source:    forecast.SourceSubscription,
+				SourceID:  subscription.ID,
+				Name:      subscription.Name,
+				Amount:    -subscription.AverageAmount,
+			})
+		}
+	}
+	return events
+}
+
+// historicalAverages menghitung rata-rata harian pemasukan & pengeluaran per kategori dan
+// per akun dari riwayat. Transaksi pembayaran tagihan dan langganan terkonfirmasi tidak
+// dihitung karena sudah diproyeksikan sebagai kejadian terjadwal.
+func historicalAverages(db *gorm.DB, userID uint, today time.Time, historyDays int, subscriptions []model.DetectedSubscription) ([]CategoryAverage, map[uint]float64, error) {
+	billTransactions := db.Model(&model.BillPayment{}).Select("bill_payments.transaction_id").
+		Joins("JOIN bills ON bills.id = bill_payments.bill_id").
+		Where("bills.user_id = ? AND bill_payments.transaction_id IS NOT NULL", userID)
+	var transactions []model.Transaction
+	if err := db.Preload("SubCategory.Category").Preload("Payee").
+		Where("user_id = ? AND type IN ? AND transaction_date >= ? AND transaction_date < ? AND id NOT IN (?)", userID,
+			[]string{model.TransactionTypeExpense, model.TransactionTypeIncome}, today.AddDate(0, 0, -historyDays), today.AddDate(0, 0, 1), billTransactions).
+		Find(&transactions).Error; err != nil {
+		return nil, nil, err
+	}
+
+	subscriptionKeys := make(map[string]bool, len(subscriptions))
+	for _, subscription := range subscriptions {
+		subscriptionKeys[subscription.Key] = true
+	}
+	categories := make(map[uint]*CategoryAverage)
+	accountDaily := make(map[uint]float64)
+	for _, transaction := range transactions {
+		if key, _ := subscriptionKey(transaction); key != "" && subscriptionKeys[key] {
+			continue
+		}
+		delta := ledger.Delta(transaction, transaction.AccountID)
+		accountDaily[transaction.AccountID] += delta / float64(historyDays)
+
+		// Transaksi lama tanpa sub-kategori dikelompokkan dengan category_id 0
+		categoryID := transaction.SubCategory.CategoryID
+		if categories[categoryID] == nil {
+			name := transaction.SubCategory.Category.Name
+			if name == "" {
+				name = "Uncategorized"
+			}
+			categories[categoryID] = &CategoryAverage{CategoryID: categoryID, Name: name, Type: transaction.Type}
+		}
+		categories[categoryID].Total += transaction.Amount
+	}
+
+	averages := []CategoryAverage{}
+	for _, average := range categories {
+		average.Total = roundMoney(average.Total)
+		average.DailyAverage = roundMoney(average.Total / float64(historyDays))
+		average.MonthlyAverage = roundMoney(average.Total / float64(historyDays) * 30)
+		averages = append(averages, *average)
+	}
+	sort.Slice(averages, func(i, j int) bool { return averages[i].Total > averages[j].Total })
+	return averages, accountDaily, nil
+}
+
+// --- Handler untuk Proyeksi Arus Kas Harian ---
+// ?days= jumlah hari ke depan (default 90), ?history_days= riwayat untuk rata-rata (default 90).
+// Memperkirakan saldo per akun dan total akun kas, serta tanggal saat akun kas diperkirakan negatif.
+func GetCashFlowForecast(c *gin.Context) {
+	days, ok := parseIntQuery(c, "days", defaultForecastDays, 1, maxForecastDays)
+	if !ok {
+		return
+	}
+	historyDays, ok := parseIntQuery(c, "history_days", defaultForecastHistory, 7, maxForecastHistory)
+	if !ok {
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	today := truncateDate(time.Now())
+	end := today.AddDate(0, 0, days)
+
+	var accounts []model.Account
+	if err := database.DB.Where("user_id = ? AND archived = ?", currentUser.ID, false).Order("id asc").Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve accounts"})
+		return
+	}
+	var subscriptions []model.DetectedSubscription
+	if err := database.DB.Where("user_id = ? AND status = ?", currentUser.ID, model.SubscriptionStatusConfirmed).Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions"})
+		return
+	}
+
+	balances := make(map[uint]float64, len(accounts))
+	for _, account := range accounts {
+		balances[account.ID] = account.Balance
+	}
+	events, err := scheduledTransactionEvents(database.DB, currentUser.ID, today, balances)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scheduled transactions"})
+		return
+	}
+	bills, err := billEvents(database.DB, currentUser.ID, today, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bills"})
+		return
+	}
+	events = append(events, bills...)
+	events = append(events, subscriptionEvents(subscriptions, today, end)...)
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.Before(events[j].Date) })
+
+	averages, accountDaily, err := historicalAverages(database.DB, currentUser.ID, today, historyDays, subscriptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate historical averages"})
+		return
+	}
+
+	forecastAccounts := make([]forecast.Account, 0, len(accounts))
+	for _, account := range accounts {
+		forecastAccounts = append(forecastAccounts, forecast.Account{
+			ID:           account.ID,
+			Name:         account.Name,
+			Balance:      balances[account.ID],
+			DailyAverage: accountDaily[account.ID],
+			Liquid:       !account.IsLiability(),
+		})
+	}
+	result := forecast.Project(today, days, forecastAccounts, events)
+
+	c.JSON(http.StatusOK, ForecastResponse{
+		From:             today,
+		To:               end,
+		HistoryDays:      historyDays,
+		CategoryAverages: averages,
+		Events:           events,
+		Accounts:         result.Accounts,
+		Overall:          result.Overall,
+		NegativeDates:    result.NegativeDates,
+	})
+}
@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/anomaly"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// Riwayat yang dipakai sebagai kebiasaan user untuk tiap transaksi
+	anomalyBaselineDays = 180
+	// Jumlah periode sebelumnya yang dirata-rata untuk mendeteksi lonjakan
+	anomalyTrailingPeriods = 3
+	defaultAnomalyDays     = 30
+	maxAnomalyDays         = 90
+	// Jendela yang diperiksa oleh pemberitahuan otomatis
+	anomalyAlertDays = 7
+)
+
+// Alasan transaksi dianggap tidak biasa
+const (
+	AnomalyReasonSubCategory = "subcategory"
+	AnomalyReasonPayee       = "payee"
+)
+
+type UnusualTransaction struct {
+	Transaction model.Transaction `json:"transaction"`
+	Reason      string            `json:"reason"`
+	Baseline    anomaly.Baseline  `json:"baseline"`
+	Score       float64           `json:"score"`
+	Ratio       float64           `json:"ratio"` // nominal dibanding rata-rata
+}
+
+type SpendingSpike struct {
+	CategoryID      uint      `json:"category_id"` // 0 = total semua pengeluaran
+	Name            string    `json:"name"`
+	PeriodStart     time.Time `json:"period_start"`
+	PeriodEnd       time.Time `json:"period_end"`
+	Amount          float64   `json:"amount"`
+	TrailingAverage float64   `json:"trailing_average"`
+	Ratio           float64   `json:"ratio"`
+}
+
+type AnomalyInsights struct {
+	From                time.Time            `json:"from"`
+	To                  time.Time            `json:"to"`
+	UnusualTransactions []UnusualTransaction `json:"unusual_transactions"`
+	SpendingSpikes      []SpendingSpike      `json:"spending_spikes"`
+}
+
+// transactionBaseline mengambil nominal transaksi dalam kelompok yang sama sebelum tanggal
+// transaksi yang dinilai, dalam jendela anomalyBaselineDays
+func transactionBaseline(group []model.Transaction, transaction model.Transaction) anomaly.Baseline {
+	date := truncateDate(transaction.TransactionDate)
+	amounts := []float64{}
+	for _, other := range group {
+		otherDate := truncateDate(other.TransactionDate)
+		if otherDate.Before(date) && !otherDate.Before(date.AddDate(0, 0, -anomalyBaselineDays)) {
+			amounts = append(amounts, other.Amount)
+		}
+	}
+	return anomaly.NewBaseline(amounts)
+}
+
+// detectAnomalies mencari transaksi pengeluaran tidak biasa dan lonjakan pengeluaran per
+// kategori dalam days hari terakhir (termasuk hari ini)
+func detectAnomalies(db *gorm.DB, userID uint, now time.Time, days int) (AnomalyInsights, error) {
+	today := truncateDate(now)
+	from := today.AddDate(0, 0, -days+1)
+	insights := AnomalyInsights{From: from, To: today, UnusualTransactions: []UnusualTransaction{}, SpendingSpikes: []SpendingSpike{}}
+
+	lookback := anomalyBaselineDays
+	if anomalyTrailingPeriods*days > lookback {
+		lookback = anomalyTrailingPeriods * days
+	}
+	var transactions []model.Transaction
+	if err := db.Preload("SubCategory.Category").Preload("Payee").
+		Where("user_id = ? AND type = ? AND transaction_date >= ? AND transaction_date < ?", userID, model.TransactionTypeExpense, from.AddDate(0, 0, -lookback), today.AddDate(0, 0, 1)).
+		Order("transaction_date asc").Find(&transactions).Error; err != nil {
+		return insights, err
+	}
+
+	bySubCategory := make(map[uint][]model.Transaction)
+	byPayee := make(map[uint][]model.Transaction)
+	for _, transaction := range transactions {
+		if transaction.SubCategoryID != nil {
+			bySubCategory[*transaction.SubCategoryID] = append(bySubCategory[*transaction.SubCategoryID], transaction)
+		}
+		if transaction.PayeeID != nil {
+			byPayee[*transaction.PayeeID] = append(byPayee[*transaction.PayeeID], transaction)
+		}
+	}
+
+	// Periode ke-0 adalah periode saat ini, sisanya periode pembanding sebelumnya
+	periodTotals := make(map[uint][]float64)
+	categoryNames := map[uint]string{0: "Total"}
+	for _, transaction := range transactions {
+		date := truncateDate(transaction.TransactionDate)
+		if !date.Before(from) {
+			var best *UnusualTransaction
+			if transaction.SubCategoryID != nil {
+				baseline := transactionBaseline(bySubCategory[*transaction.SubCategoryID], transaction)
+				if score, ok := anomaly.Outlier(transaction.Amount, baseline); ok {
+					best = &UnusualTransaction{Transaction: transaction, Reason: AnomalyReasonSubCategory, Baseline: baseline, Score: score}
+				}
+			}
+			if transaction.PayeeID != nil {
+				baseline := transactionBaseline(byPayee[*transaction.PayeeID], transaction)
+				if score, ok := anomaly.Outlier(transaction.Amount, baseline); ok && (best == nil || score > best.Score) {
+					best = &UnusualTransaction{Transaction: transaction, Reason: AnomalyReasonPayee, Baseline: baseline, Score: score}
+				}
+			}
+			if best != nil {
+				best.Ratio = roundMoney(transaction.Amount / best.Baseline.Mean)
+				insights.UnusualTransactions = append(insights.UnusualTransactions, *best)
+			}
+		}
+
+		period := int(today.Sub(date).Hours()/24) / days
+		if period > anomalyTrailingPeriods {
+			continue
+		}
+		// Transaksi tanpa sub-kategori hanya dihitung pada total
+		categoryIDs := []uint{0}
+		if transaction.SubCategoryID != nil {
+			categoryIDs = append(categoryIDs, transaction.SubCategory.CategoryID)
+			categoryNames[transaction.SubCategory.CategoryID] = transaction.SubCategory.Category.Name
+		}
+		for _, id := range categoryIDs {
+			if periodTotals[id] == nil {
+				periodTotals[id] = make([]float64, anomalyTrailingPeriods+1)
+			}
+			periodTotals[id][period] += transaction.Amount
+		}
+	}
+
+	for categoryID, totals := range periodTotals {
+		ratio, ok := anomaly.Spike(totals[0], totals[1:])
+		if !ok {
+			continue
+		}
+		average := 0.0
+		for _, total := range totals[1:] {
+			average += total
+		}
+		insights.SpendingSpikes = append(insights.SpendingSpikes, SpendingSpike{
+			CategoryID:      categoryID,
+			Name:            categoryNames[categoryID],
+			PeriodStart:     from,
+			PeriodEnd:       today,
+			Amount:          roundMoney(totals[0]),
+			TrailingAverage: roundMoney(average / anomalyTrailingPeriods),
+			Ratio:           ratio,
+		})
+	}
+
+	sort.SliceStable(insights.UnusualTransactions, func(i, j int) bool {
+		return insights.UnusualTransactions[i].Score > insights.UnusualTransactions[j].Score
+	})
+	sort.SliceStable(insights.SpendingSpikes, func(i, j int) bool {
+		return insights.SpendingSpikes[i].Ratio > insights.SpendingSpikes[j].Ratio
+	})
+	return insights, nil
+}
+
+// --- Handler untuk Insight Pengeluaran Tidak Biasa (opsional ?days=, default 30) ---
+func GetAnomalyInsights(c *gin.Context) {
+	days, ok := parseIntQuery(c, "days", defaultAnomalyDays, 1, maxAnomalyDays)
+	if !ok {
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	insights, err := detectAnomalies(database.DB, currentUser.ID, time.Now(), days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect spending anomalies"})
+		return
+	}
+	c.JSON(http.StatusOK, insights)
+}
+
+// sendAnomalyAlert mengirim satu pemberitahuan jika reference belum pernah diberitahukan
+func sendAnomalyAlert(db *gorm.DB, dispatcher *notify.Dispatcher, now time.Time, message notify.Message, reference string) (bool, error) {
+	var count int64
+	if err := db.Model(&model.AnomalyAlert{}).Where("user_id = ? AND reference = ?", message.UserID, reference).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	delivered, err := dispatcher.Send(message)
+	if err != nil {
+		log.Println("Failed to send anomaly alert:", err)
+		// Hanya dicoba lagi jika tidak ada channel yang berhasil menerima
+		if delivered == 0 {
+			return false, nil
+		}
+	}
+	alert := model.AnomalyAlert{UserID: message.UserID, Kind: message.Kind, Reference: reference, SentAt: now}
+	return true, db.Create(&alert).Error
+}
+
+// SendAnomalyAlerts memberitahukan transaksi tidak biasa dan lonjakan pengeluaran dalam
+// anomalyAlertDays hari terakhir. Transaksi diberitahukan sekali, lonjakan sekali per
+// kategori per bulan.
+func SendAnomalyAlerts(db *gorm.DB, dispatcher *notify.Dispatcher, now time.Time) (int, error) {
+	var userIDs []uint
+	if err := db.Model(&model.User{}).Pluck("id", &userIDs).Error; err != nil {
+		return 0, err
+	}
+	sent := 0
+	for _, userID := range userIDs {
+		insights, err := detectAnomalies(db, userID, now, anomalyAlertDays)
+		if err != nil {
+			return sent, err
+		}
+		for _, unusual := range insights.UnusualTransactions {
+			transaction := unusual.Transaction
+			ok, err := sendAnomalyAlert(db, dispatcher, now, notify.Message{
+				UserID:  userID,
+				Kind:    notify.KindUnusualTransaction,
+				Subject: fmt.Sprintf("Unusual transaction of %.2f", transaction.Amount),
+				Body: fmt.Sprintf("%s on %s is %.1fx your usual %.2f for this %s",
+					transaction.Notes, transaction.TransactionDate.Format("2006-01-02"), unusual.Ratio, unusual.Baseline.Mean, unusual.Reason),
+				Data: map[string]interface{}{"transaction_id": transaction.ID, "amount": transaction.Amount, "reason": unusual.Reason, "score": unusual.Score},
+			}, "transaction:"+strconv.Itoa(int(transaction.ID)))
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+		for _, spike := range insights.SpendingSpikes {
+			ok, err := sendAnomalyAlert(db, dispatcher, now, notify.Message{
+				UserID:  userID,
+				Kind:    notify.KindSpendingSpike,
+				Subject: fmt.Sprintf("Spending spike in %s", spike.Name),
+				Body: fmt.Sprintf("You spent %.2f on %s in the last %d days, %.1fx your trailing average of %.2f",
+					spike.Amount, spike.Name, anomalyAlertDays, spike.Ratio, spike.TrailingAverage),
+				Data: map[string]interface{}{"category_id": spike.CategoryID, "amount": spike.Amount, "trailing_average": spike.TrailingAverage, "ratio": spike.Ratio},
+			}, fmt.Sprintf("spike:%d:%s", spike.CategoryID, now.Format("2006-01")))
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+// StartAnomalyAlerts menjalankan SendAnomalyAlerts secara berkala di background
+func StartAnomalyAlerts(interval time.Duration) {
+	go func() {
+		for {
+			if _, err := SendAnomalyAlerts(database.DB, notify.Default, time.Now()); err != nil {
+				log.Println("Failed to process anomaly alerts:", err)
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
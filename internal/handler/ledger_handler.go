@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type LedgerEntry struct {
+	Transaction    model.Transaction `json:"transaction"`
+	Amount         float64           `json:"amount"` // perubahan saldo akun ini (negatif = keluar)
+	RunningBalance float64           `json:"running_balance"`
+}
+
+type LedgerResponse struct {
+	AccountID      uint          `json:"account_id"`
+	OpeningBalance float64       `json:"opening_balance"`
+	ClosingBalance float64       `json:"closing_balance"`
+	Entries        []LedgerEntry `json:"entries"`
+}
+
+// accountTransactions mengambil semua transaksi yang menyentuh akun, termasuk
+// transfer di mana akun ini menjadi DestinationAccountID.
+func accountTransactions(db *gorm.DB, accountID uint) *gorm.DB {
+	return db.Where("(account_id = ? OR (type = ? AND destination_account_id = ?))", accountID, model.TransactionTypeTransfer, accountID)
+}
+
+// accountBalanceAt menghitung saldo akun pada akhir tanggal date dengan
+// membatalkan transaksi setelah tanggal tersebut dari saldo saat ini.
+func accountBalanceAt(db *gorm.DB, account model.Account, date time.Time) (float64, error) {
+	boundary := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	var transactions []model.Transaction
+	if err := accountTransactions(db, account.ID).Where("transaction_date >= ?", boundary).Find(&transactions).Error; err != nil {
+		return 0, err
+	}
+	balance := account.Balance
+	for _, transaction := range transactions {
+		balance -= ledger.Delta(transaction, account.ID)
+	}
+	return roundMoney(balance), nil
+}
+
+// parseDateQuery membaca parameter tanggal opsional berformat YYYY-MM-DD
+func parseDateQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
+}
+
+// --- Handler untuk Buku Besar Akun dengan Saldo Berjalan ---
+func GetAccountLedger(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+		return
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var account model.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	var transactions []model.Transaction
+	if err := accountTransactions(database.DB.Preload("SubCategory").Preload("SubCategory.Category"), account.ID).
+		Order("transaction_date asc, id asc").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions"})
+		return
+	}
+
+	// Saldo awal = saldo saat ini dikurangi efek semua transaksi
+	running := account.Balance
+	for _, transaction := range transactions {
+		running -= ledger.Delta(transaction, account.ID)
+	}
+
+	response := LedgerResponse{AccountID: account.ID, Entries: []LedgerEntry{}}
+	response.OpeningBalance = roundMoney(running)
+	for _, transaction := range transactions {
+		if to != nil && !transaction.TransactionDate.Before(to.AddDate(0, 0, 1)) {
+			break
+		}
+		amount := ledger.Delta(transaction, account.ID)
+		if from != nil && transaction.TransactionDate.Before(*from) {
+			running += amount
+			response.OpeningBalance = roundMoney(running)
+			continue
+		}
+		running += amount
+		response.Entries = append(response.Entries, LedgerEntry{
+			Transaction:    transaction,
+			Amount:         amount,
+			RunningBalance: roundMoney(running),
+		})
+	}
+	response.ClosingBalance = roundMoney(running)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// --- Handler untuk Saldo Akun pada Tanggal Tertentu ---
+func GetAccountBalanceAt(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	date, err := parseDateQuery(c, "date")
+	if err != nil || date == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date is required in YYYY-MM-DD format"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var account model.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	balance, err := accountBalanceAt(database.DB, account, *date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate balance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"account_id": account.ID, "date": date.Format("2006-01-02"), "balance": balance})
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/debt"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Cicilan minimum (persen dari saldo) untuk utang yang tidak punya jadwal angsuran
+const defaultMinimumPaymentPercent = 2.0
+
+type LoanDetailInput struct {
+	Principal    float64   `json:"principal" binding:"required,gt=0"`
+	InterestRate *float64  `json:"interest_rate" binding:"required,gte=0"` // persen per tahun
+	TermMonths   int       `json:"term_months" binding:"required,gt=0,lte=600"`
+	StartDate    time.Time `json:"start_date" binding:"required"`
+	PaymentDay   int       `json:"payment_day" binding:"required,min=1,max=31"`
+}
+
+type LoanSummary struct {
+	Detail            model.LoanDetail `json:"detail"`
+	MonthlyPayment    float64          `json:"monthly_payment"`
+	Outstanding       float64          `json:"outstanding"`
+	PrincipalPaid     float64          `json:"principal_paid"`
+	InterestPaid      float64          `json:"interest_paid"`
+	NextDueDate       *time.Time       `json:"next_due_date"`
+	RemainingPayments int              `json:"remaining_payments"`
+}
+
+// loanPaymentInterest menentukan bagian bunga dari transfer ke akun tujuan. Bunga manual
+// hanya boleh untuk akun utang; untuk akun pinjaman dengan detail, bunga dihitung dari
+// sisa pokok saat ini dengan bunga bulanan. Akun lain tidak punya bagian bunga.
+func loanPaymentInterest(tx *gorm.DB, account model.Account, amount float64, override *float64) (float64, error) {
+	if override != nil {
+		if *override > 0 && !account.IsLiability() {
+			return 0, &transactionError{Message: "interest_amount is only allowed for payments to loan or credit card accounts"}
+		}
+		if *override > amount {
+			return 0, &transactionError{Message: "interest_amount cannot exceed the payment amount"}
+		}
+		return roundMoney(*override), nil
+	}
+	if account.Type != model.AccountTypeLoan {
+		return 0, nil
+	}
+	var detail model.LoanDetail
+	if err := tx.Where("account_id = ?", account.ID).First(&detail).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	outstanding := math.Max(-account.Balance, 0)
+	return math.Min(debt.MonthlyInterest(outstanding, detail.InterestRate), amount), nil
+}
+
+// findLoanAccount mengambil akun pinjaman milik user
+func findLoanAccount(c *gin.Context) (model.Account, bool) {
+	var account model.Account
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return account, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return account, false
+	}
+	if account.Type != model.AccountTypeLoan {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not a loan account"})
+		return account, false
+	}
+	return account, true
+}
+
+func findLoanDetail(c *gin.Context, account model.Account) (model.LoanDetail, bool) {
+	var detail model.LoanDetail
+	if err := database.DB.Where("account_id = ?", account.ID).First(&detail).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Loan details have not been set for this account"})
+		return detail, false
+	}
+	return detail, true
+}
+
+// --- Handler untuk Mengatur Detail Pinjaman pada Akun Pinjaman ---
+func SetLoanDetail(c *gin.Context) {
+	account, ok := findLoanAccount(c)
+	if !ok {
+		return
+	}
+	var input LoanDetailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var detail model.LoanDetail
+	database.DB.Where("account_id = ?", account.ID).First(&detail)
+	detail.AccountID = account.ID
+	detail.Principal = input.Principal
+	detail.InterestRate = *input.InterestRate
+	detail.TermMonths = input.TermMonths
+	detail.StartDate = time.Date(input.StartDate.Year(), input.StartDate.Month(), input.StartDate.Day(), 0, 0, 0, 0, time.UTC)
+	detail.PaymentDay = input.PaymentDay
+	if err := database.DB.Omit("Account").Save(&detail).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save loan details"})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// --- Handler untuk Ringkasan Pinjaman (sisa pokok, pokok & bunga yang sudah dibayar) ---
+func GetLoanDetail(c *gin.Context) {
+	account, ok := findLoanAccount(c)
+	if !ok {
+		return
+	}
+	detail, ok := findLoanDetail(c, account)
+	if !ok {
+		return
+	}
+
+	var payments []model.Transaction
+	if err := database.DB.Where("type = ? AND destination_account_id = ?", model.TransactionTypeTransfer, account.ID).
+		Find(&payments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve loan payments"})
+		return
+	}
+
+	summary := LoanSummary{
+		Detail:         detail,
+		MonthlyPayment: debt.MonthlyPayment(detail.Principal, detail.InterestRate, detail.TermMonths),
+		Outstanding:    roundMoney(math.Max(-account.Balance, 0)),
+	}
+	for _, payment := range payments {
+		summary.PrincipalPaid += payment.Amount - payment.InterestAmount
+		summary.InterestPaid += payment.InterestAmount
+	}
+	summary.PrincipalPaid = roundMoney(summary.PrincipalPaid)
+	summary.InterestPaid = roundMoney(summary.InterestPaid)
+
+	// Angsuran berikutnya = angsuran pertama yang jatuh tempo hari ini atau setelahnya
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for number := 1; number <= detail.TermMonths; number++ {
+		dueDate := debt.DueDate(detail.StartDate, detail.PaymentDay, number)
+		if !dueDate.Before(today) {
+			if summary.Outstanding > 0 {
+				summary.NextDueDate = &dueDate
+			}
+			summary.RemainingPayments = detail.TermMonths - number + 1
+			break
+		}
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// --- Handler untuk Jadwal Angsuran (Amortisasi) Pinjaman ---
+func GetLoanSchedule(c *gin.Context) {
+	account, ok := findLoanAccount(c)
+	if !ok {
+		return
+	}
+	detail, ok := findLoanDetail(c, account)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"monthly_payment": debt.MonthlyPayment(detail.Principal, detail.InterestRate, detail.TermMonths),
+		"schedule":        debt.Schedule(detail.Principal, detail.InterestRate, detail.TermMonths, detail.StartDate, detail.PaymentDay),
+	})
+}
+
+// --- Handler untuk Simulasi Pelunasan Semua Utang: Snowball vs Avalanche ---
+// ?extra= adalah dana tambahan per bulan di atas total cicilan minimum.
+func GetDebtPayoffSimulation(c *gin.Context) {
+	extra := 0.0
+	if value := c.Query("extra"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "extra must be a non-negative number"})
+			return
+		}
+		extra = parsed
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var accounts []model.Account
+	if err := database.DB.Where("user_id = ? AND type IN ? AND archived = ? AND balance < 0", currentUser.ID,
+		[]string{model.AccountTypeLoan, model.AccountTypeCreditCard}, false).Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve debts"})
+		return
+	}
+	debts, err := debtsForAccounts(database.DB, accounts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve debt details"})
+		return
+	}
+
+	snowball := debt.Simulate(debts, extra, debt.StrategySnowball)
+	avalanche := debt.Simulate(debts, extra, debt.StrategyAvalanche)
+	c.JSON(http.StatusOK, gin.H{
+		"extra":                         extra,
+		"debts":                         debts,
+		"snowball":                      snowball,
+		"avalanche":                     avalanche,
+		"interest_saved_with_avalanche": roundMoney(snowball.TotalInterest - avalanche.TotalInterest),
+	})
+}
+
+// debtsForAccounts menyusun data simulasi dari akun utang. Pinjaman dengan detail memakai
+// angsuran bulanannya sebagai cicilan minimum, kartu kredit memakai aturan pembayaran
+// minimum kartunya, utang lain memakai persentase dari saldo.
+func debtsForAccounts(db *gorm.DB, accounts []model.Account) ([]debt.Debt, error) {
+	debts := []debt.Debt{}
+	for _, account := range accounts {
+		item := debt.Debt{
+			ID:             account.ID,
+			Name:           account.Name,
+			Balance:        roundMoney(-account.Balance),
+			MinimumPayment: roundMoney(-account.Balance * defaultMinimumPaymentPercent / 100),
+		}
+		var detail model.LoanDetail
+		err := db.Where("account_id = ?", account.ID).First(&detail).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			item.AnnualRate = detail.InterestRate
+			item.MinimumPayment = debt.MonthlyPayment(detail.Principal, detail.InterestRate, detail.TermMonths)
+		}
+		var card model.CreditCardDetail
+		err = db.Where("account_id = ?", account.ID).First(&card).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if err == nil {
+			item.AnnualRate = card.InterestRate
+			item.MinimumPayment = creditCardMinimumPayment(card, item.Balance)
+		}
+		debts = append(debts, item)
+	}
+	return debts, nil
+}
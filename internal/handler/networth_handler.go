@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Batas jumlah titik harian agar query riwayat tidak terlalu berat
+const maxDailyNetWorthPoints = 731
+
+type NetWorthAccount struct {
+	ID          uint    `json:"id"`
+	Name        string  `json:"name"`
+	Type        string  `json:"type"`
+	Balance     float64 `json:"balance"`
+	IsLiability bool    `json:"is_liability"`
+}
+
+type NetWorthResponse struct {
+	Assets      float64           `json:"assets"`
+	Liabilities float64           `json:"liabilities"`
+	NetWorth    float64           `json:"net_worth"`
+	Accounts    []NetWorthAccount `json:"accounts"`
+}
+
+type NetWorthPoint struct {
+	Date        time.Time `json:"date"`
+	Assets      float64   `json:"assets"`
+	Liabilities float64   `json:"liabilities"`
+	NetWorth    float64   `json:"net_worth"`
+}
+
+// netWorthOf menjumlahkan aset dan liabilitas dari saldo per akun.
+// Liabilitas dilaporkan sebagai angka positif (jumlah utang).
+func netWorthOf(accounts []model.Account, balances map[uint]float64) (float64, float64) {
+	var assets, liabilities float64
+	for _, account := range accounts {
+		if account.IsLiability() {
+			liabilities -= balances[account.ID]
+		} else {
+			assets += balances[account.ID]
+		}
+	}
+	return roundMoney(assets), roundMoney(liabilities)
+}
+
+// --- Handler untuk Mendapatkan Net Worth Saat Ini ---
+func GetNetWorth(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var accounts []model.Account
+	if err := database.DB.Where("user_id = ?", currentUser.ID).Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve accounts"})
+		return
+	}
+
+	response := NetWorthResponse{Accounts: []NetWorthAccount{}}
+	balances := make(map[uint]float64)
+	for _, account := range accounts {
+		balances[account.ID] = account.Balance
+		response.Accounts = append(response.Accounts, NetWorthAccount{
+			ID:          account.ID,
+			Name:        account.Name,
+			Type:        account.Type,
+			Balance:     account.Balance,
+			IsLiability: account.IsLiability(),
+		})
+	}
+	response.Assets, response.Liabilities = netWorthOf(accounts, balances)
+	response.NetWorth = roundMoney(response.Assets - response.Liabilities)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// --- Handler untuk Riwayat Net Worth (harian/bulanan) ---
+// Saldo masa lalu direkonstruksi dengan berjalan mundur dari Account.Balance saat ini
+// dan membatalkan transaksi yang terjadi setelah tiap titik.
+func GetNetWorthHistory(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	interval := c.DefaultQuery("interval", "monthly")
+	if interval != "daily" && interval != "monthly" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be daily or monthly"})
+		return
+	}
+
+	now := time.Now()
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(-1, 0, 0)
+	if interval == "daily" {
+		from = to.AddDate(0, 0, -30)
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in YYYY-MM-DD format"})
+			return
+		}
+		from = parsed
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be in YYYY-MM-DD format"})
+			return
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+
+	// Titik riwayat adalah akhir hari (daily) atau akhir bulan (monthly)
+	var points []time.Time
+	if interval == "daily" {
+		if int(to.Sub(from).Hours()/24)+1 > maxDailyNetWorthPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Range is too long for daily interval"})
+			return
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			points = append(points, d)
+		}
+	} else {
+		for m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); !m.After(to); m = m.AddDate(0, 1, 0) {
+			endOfMonth := m.AddDate(0, 1, -1)
+			if endOfMonth.After(to) {
+				endOfMonth = to
+			}
+			points = append(points, endOfMonth)
+		}
+	}
+
+	var accounts []model.Account
+	if err := database.DB.Where("user_id = ?", currentUser.ID).Find(&accounts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve accounts"})
+		return
+	}
+	balances := make(map[uint]float64)
+	for _, account := range accounts {
+		balances[account.ID] = account.Balance
+	}
+
+	// Semua transaksi setelah titik pertama, terbaru lebih dulu
+	var transactions []model.Transaction
+	if err := database.DB.Where("user_id = ? AND transaction_date >= ?", currentUser.ID, points[0].AddDate(0, 0, 1)).
+		Order("transaction_date desc").Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions"})
+		return
+	}
+
+	history := make([]NetWorthPoint, len(points))
+	next := 0
+	for i := len(points) - 1; i >= 0; i-- {
+		boundary := points[i].AddDate(0, 0, 1)
+		for next < len(transactions) && !transactions[next].TransactionDate.Before(boundary) {
+			ledger.Revert(balances, transactions[next])
+			next++
+		}
+		assets, liabilities := netWorthOf(accounts, balances)
+		history[i] = NetWorthPoint{
+			Date:        points[i],
+			Assets:      assets,
+			Liabilities: liabilities,
+			NetWorth:    roundMoney(assets - liabilities),
+		}
+	}
+
+	c.JSON(http.StatusOK, history)
+}
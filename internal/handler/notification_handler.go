@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationResponse struct {
+	ID        uint            `json:"id"`
+	Kind      string          `json:"kind"`
+	Subject   string          `json:"subject"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type NotificationPreferenceInput struct {
+	Kind    string `json:"kind" binding:"required"` // salah satu jenis pemberitahuan, atau "*" untuk semua
+	Channel string `json:"channel" binding:"required"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+type NotificationPreferenceResponse struct {
+	Kind    string `json:"kind"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// --- Handler untuk Kotak Masuk Pemberitahuan (opsional ?unread=true) ---
+func GetNotifications(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	query := database.DB.Where("user_id = ?", currentUser.ID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	var notifications []model.Notification
+	if err := query.Order("created_at desc").Limit(200).Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notifications"})
+		return
+	}
+	var unread int64
+	database.DB.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", currentUser.ID).Count(&unread)
+
+	response := make([]NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		item := NotificationResponse{
+			ID:        notification.ID,
+			Kind:      notification.Kind,
+			Subject:   notification.Subject,
+			Body:      notification.Body,
+			ReadAt:    notification.ReadAt,
+			CreatedAt: notification.CreatedAt,
+		}
+		if notification.Data != "" {
+			item.Data = json.RawMessage(notification.Data)
+		}
+		response = append(response, item)
+	}
+	c.JSON(http.StatusOK, gin.H{"notifications": response, "unread_count": unread})
+}
+
+// --- Handler untuk Menandai Pemberitahuan Sudah Dibaca ---
+func MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	var notification model.Notification
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := database.DB.Model(&notification).Update("read_at", now).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+// --- Handler untuk Menandai Semua Pemberitahuan Sudah Dibaca ---
+func MarkAllNotificationsRead(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	result := database.DB.Model(&model.Notification{}).Where("user_id = ? AND read_at IS NULL", currentUser.ID).Update("read_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notifications marked as read", "updated": result.RowsAffected})
+}
+
+// --- Handler untuk Menghapus Pemberitahuan ---
+func DeleteNotification(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	result := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).Delete(&model.Notification{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification deleted successfully"})
+}
+
+// --- Handler untuk Melihat Preferensi Pemberitahuan ---
+// Mengembalikan status efektif setiap jenis pada setiap channel yang terdaftar.
+func GetNotificationPreferences(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	channels := notify.Default.Channels()
+	preferences := notify.DBPreferences{DB: database.DB}
+
+	response := []NotificationPreferenceResponse{}
+	for _, kind := range notify.Kinds {
+		for _, channel := range channels {
+			response = append(response, NotificationPreferenceResponse{
+				Kind:    kind,
+				Channel: channel,
+				Enabled: preferences.Enabled(currentUser.ID, kind, channel),
+			})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"kinds": notify.Kinds, "channels": channels, "preferences": response})
+}
+
+// --- Handler untuk Mengubah Preferensi Pemberitahuan ---
+// Menerima daftar {kind, channel, enabled}; kind "*" berlaku untuk semua jenis pada channel tersebut.
+func UpdateNotificationPreferences(c *gin.Context) {
+	var inputs []NotificationPreferenceInput
+	if err := c.ShouldBindJSON(&inputs); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(inputs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one preference is required"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	channels := notify.Default.Channels()
+
+	preferences := make([]model.NotificationPreference, 0, len(inputs))
+	for _, input := range inputs {
+		if input.Kind != "*" && !slices.Contains(notify.Kinds, input.Kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown notification kind: " + input.Kind})
+			return
+		}
+		if !slices.Contains(channels, input.Channel) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown notification channel: " + input.Channel})
+			return
+		}
+		preferences = append(preferences, model.NotificationPreference{
+			UserID:  currentUser.ID,
+			Kind:    input.Kind,
+			Channel: input.Channel,
+			Enabled: *input.Enabled,
+		})
+	}
+
+	err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "kind"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&preferences).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification preferences updated successfully"})
+}
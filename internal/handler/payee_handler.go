@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const ErrCodePayeeNotFound = "PAYEE_NOT_FOUND"
+
+type PayeeInput struct {
+	Name                 string   `json:"name" binding:"required,max=255"`
+	Aliases              []string `json:"aliases" binding:"omitempty,dive,required,max=255"`
+	DefaultSubCategoryID *uint    `json:"default_sub_category_id"`
+	DefaultAccountID     *uint    `json:"default_account_id"`
+}
+
+type PayeeTotal struct {
+	PayeeID             uint      `json:"payee_id"`
+	Name                string    `json:"name"`
+	TotalExpense        float64   `json:"total_expense"`
+	TotalIncome         float64   `json:"total_income"`
+	TransactionCount    int64     `json:"transaction_count"`
+	LastTransactionDate time.Time `json:"last_transaction_date"`
+}
+
+// findPayeeByName mencari payee milik user berdasarkan nama atau alias (tanpa membedakan huruf besar/kecil)
+func findPayeeByName(tx *gorm.DB, userID uint, name string) (model.Payee, error) {
+	var payee model.Payee
+	name = strings.ToLower(strings.TrimSpace(name))
+	aliasPayeeIDs := tx.Model(&model.PayeeAlias{}).Select("payee_id").Where("LOWER(alias) = ?", name)
+	err := tx.Where("user_id = ? AND (LOWER(name) = ? OR id IN (?))", userID, name, aliasPayeeIDs).First(&payee).Error
+	return payee, err
+}
+
+// validatePayeeInput memastikan default sub-kategori/akun milik user dan nama/alias
+// tidak bentrok dengan payee lain.
+func validatePayeeInput(tx *gorm.DB, userID uint, input PayeeInput, excludeID uint) error {
+	if input.DefaultSubCategoryID != nil {
+		var count int64
+		tx.Model(&model.SubCategory{}).Where("id = ? AND user_id = ?", *input.DefaultSubCategoryID, userID).Count(&count)
+		if count == 0 {
+			return errors.New("default sub-category not found")
+		}
+	}
+	if input.DefaultAccountID != nil {
+		var count int64
+		tx.Model(&model.Account{}).Where("id = ? AND user_id = ?", *input.DefaultAccountID, userID).Count(&count)
+		if count == 0 {
+			return errors.New("default account not found")
+		}
+	}
+	for _, name := range append([]string{input.Name}, input.Aliases...) {
+		existing, err := findPayeeByName(tx, userID, name)
+		if err == nil && existing.ID != excludeID {
+			return errors.New("name or alias \"" + name + "\" is already used by payee " + existing.Name)
+		}
+	}
+	return nil
+}
+
+func payeeAliases(aliases []string) []model.PayeeAlias {
+	result := []model.PayeeAlias{}
+	seen := make(map[string]bool)
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || seen[strings.ToLower(alias)] {
+			continue
+		}
+		seen[strings.ToLower(alias)] = true
+		result = append(result, model.PayeeAlias{Alias: alias})
+	}
+	return result
+}
+
+// resolveTransactionPayee mencari payee dari payee_id atau payee_name (payee baru dibuat jika
+// namanya belum dikenal), lalu mengisi sub-kategori dan akun yang kosong dari default payee.
+func resolveTransactionPayee(tx *gorm.DB, userID uint, input *TransactionInput) error {
+	var payee model.Payee
+	switch {
+	case input.PayeeID != nil:
+		if err := tx.Where("id = ? AND user_id = ?", *input.PayeeID, userID).First(&payee).Error; err != nil {
+			return &transactionError{Code: ErrCodePayeeNotFound, Message: "payee not found"}
+		}
+	case strings.TrimSpace(input.PayeeName) != "":
+		found, err := findPayeeByName(tx, userID, input.PayeeName)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			found = model.Payee{UserID: userID, Name: strings.TrimSpace(input.PayeeName)}
+			err = tx.Create(&found).Error
+		}
+		if err != nil {
+			return err
+		}
+		payee = found
+		input.PayeeID = &payee.ID
+	default:
+		return nil
+	}
+
+	if input.SubCategoryID == nil && input.Type != model.TransactionTypeTransfer {
+		input.SubCategoryID = payee.DefaultSubCategoryID
+	}
+	if input.AccountID == 0 && payee.DefaultAccountID != nil {
+		input.AccountID = *payee.DefaultAccountID
+	}
+	return nil
+}
+
+// replacePayeeDefaults mengganti (atau mengosongkan jika replacement nil) default payee
+// yang menunjuk ke sub-kategori/akun yang akan dihapus atau digabung.
+func replacePayeeDefaults(tx *gorm.DB, column string, ids []uint, replacement *uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&model.Payee{}).Where(column+" IN ?", ids).Update(column, replacement).Error
+}
+
+// --- Handler untuk Membuat Payee ---
+func CreatePayee(c *gin.Context) {
+	var input PayeeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	if err := validatePayeeInput(database.DB, currentUser.ID, input, 0); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payee := model.Payee{
+		UserID:               currentUser.ID,
+		Name:                 strings.TrimSpace(input.Name),
+		DefaultSubCategoryID: input.DefaultSubCategoryID,
+		DefaultAccountID:     input.DefaultAccountID,
+		Aliases:              payeeAliases(input.Aliases),
+	}
+	if err := database.DB.Create(&payee).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create payee"})
+		return
+	}
+	c.JSON(http.StatusOK, payee)
+}
+
+// --- Handler untuk Mengambil Daftar Payee (opsional ?search=) ---
+func GetPayees(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	query := database.DB.Preload("Aliases").Where("user_id = ?", currentUser.ID).Order("name asc")
+	if search := strings.ToLower(strings.TrimSpace(c.Query("search"))); search != "" {
+		aliasPayeeIDs := database.DB.Model(&model.PayeeAlias{}).Select("payee_id").Where("LOWER(alias) LIKE ?", "%"+search+"%")
+		query = query.Where("LOWER(name) LIKE ? OR id IN (?)", "%"+search+"%", aliasPayeeIDs)
+	}
+
+	var payees []model.Payee
+	if err := query.Find(&payees).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve payees"})
+		return
+	}
+	c.JSON(http.StatusOK, payees)
+}
+
+// --- Handler untuk Mengubah Payee (alias diganti seluruhnya) ---
+func UpdatePayee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input PayeeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var payee model.Payee
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&payee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payee not found"})
+		return
+	}
+	if err := validatePayeeInput(database.DB, currentUser.ID, input, payee.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		payee.Name = strings.TrimSpace(input.Name)
+		payee.DefaultSubCategoryID = input.DefaultSubCategoryID
+		payee.DefaultAccountID = input.DefaultAccountID
+		if err := tx.Save(&payee).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("payee_id = ?", payee.ID).Delete(&model.PayeeAlias{}).Error; err != nil {
+			return err
+		}
+		aliases := payeeAliases(input.Aliases)
+		for i := range aliases {
+			aliases[i].PayeeID = payee.ID
+		}
+		if len(aliases) == 0 {
+			return nil
+		}
+		return tx.Create(&aliases).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update payee"})
+		return
+	}
+
+	database.DB.Preload("Aliases").First(&payee, payee.ID)
+	c.JSON(http.StatusOK, payee)
+}
+
+// --- Handler untuk Menghapus Payee (transaksinya tetap ada tanpa payee) ---
+func DeletePayee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var payee model.Payee
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&payee).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payee not found"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Transaction{}).Where("payee_id = ?", payee.ID).Update("payee_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("payee_id = ?", payee.ID).Delete(&model.PayeeAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.DetectedSubscription{}).Where("payee_id = ?", payee.ID).Update("payee_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&payee).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete payee"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Payee deleted successfully"})
+}
+
+// --- Handler untuk Total Pengeluaran/Pemasukan per Payee (opsional ?from=&to=) ---
+func GetPayeeTotals(c *gin.Context) {
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date, use YYYY-MM-DD"})
+		return
+	}
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date, use YYYY-MM-DD"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	query := database.DB.Model(&model.Transaction{}).
+		Select("payee_id, "+
+			"SUM(CASE WHEN type = ? THEN amount ELSE 0 END) AS total_expense, "+
+			"SUM(CASE WHEN type = ? THEN amount ELSE 0 END) AS total_income, "+
+			"COUNT(*) AS transaction_count, MAX(transaction_date) AS last_transaction_date",
+			model.TransactionTypeExpense, model.TransactionTypeIncome).
+		Where("user_id = ? AND payee_id IS NOT NULL", currentUser.ID)
+	if from != nil {
+		query = query.Where("transaction_date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("transaction_date < ?", to.AddDate(0, 0, 1))
+	}
+
+	var totals []PayeeTotal
+	if err := query.Group("payee_id").Scan(&totals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate payee totals"})
+		return
+	}
+
+	var payees []model.Payee
+	database.DB.Where("user_id = ?", currentUser.ID).Find(&payees)
+	names := make(map[uint]string, len(payees))
+	for _, payee := range payees {
+		names[payee.ID] = payee.Name
+	}
+	for i := range totals {
+		totals[i].Name = names[totals[i].PayeeID]
+		totals[i].TotalExpense = roundMoney(totals[i].TotalExpense)
+		totals[i].TotalIncome = roundMoney(totals[i].TotalIncome)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].TotalExpense > totals[j].TotalExpense
+	})
+
+	if totals == nil {
+		totals = []PayeeTotal{}
+	}
+	c.JSON(http.StatusOK, totals)
+}
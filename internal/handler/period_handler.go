@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Periode budget diidentifikasi dengan kunci (Year, Month, Week):
+//   - monthly: Year + Month, periode dimulai pada PeriodStartDay bulan tersebut (mis. 25 Okt - 24 Nov)
+//   - weekly:  Year + Week, minggu ke-1 dimulai pada hari PeriodStartDay (0 = Minggu) pertama di tahun itu
+//   - yearly:  Year, periode dimulai pada PeriodStartDay bulan PeriodStartMonth
+type periodKey struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Week  int `json:"week"`
+}
+
+type BudgetPeriodInput struct {
+	BudgetPeriod     string `json:"budget_period" binding:"required,oneof=monthly weekly yearly"`
+	PeriodStartDay   int    `json:"period_start_day"`
+	PeriodStartMonth int    `json:"period_start_month" binding:"omitempty,min=1,max=12"`
+}
+
+type PeriodResponse struct {
+	periodKey
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// firstWeekStart mengembalikan awal minggu ke-1 untuk tahun tertentu
+func firstWeekStart(user model.User, year int) time.Time {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	offset := (user.PeriodStartDay - int(jan1.Weekday()) + 7) % 7
+	return jan1.AddDate(0, 0, offset)
+}
+
+// periodRange mengembalikan rentang [start, end) untuk sebuah kunci periode
+func periodRange(user model.User, key periodKey) (time.Time, time.Time) {
+	switch user.BudgetPeriod {
+	case model.BudgetPeriodWeekly:
+		start := firstWeekStart(user, key.Year).AddDate(0, 0, 7*(key.Week-1))
+		return start, start.AddDate(0, 0, 7)
+	case model.BudgetPeriodYearly:
+		start := time.Date(key.Year, time.Month(user.PeriodStartMonth), user.PeriodStartDay, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(1, 0, 0)
+	default:
+		start := time.Date(key.Year, time.Month(key.Month), user.PeriodStartDay, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// periodContaining mencari kunci periode yang mencakup tanggal t
+func periodContaining(user model.User, t time.Time) periodKey {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch user.BudgetPeriod {
+	case model.BudgetPeriodWeekly:
+		year := date.Year()
+		if date.Before(firstWeekStart(user, year)) {
+			year--
+		}
+		days := int(date.Sub(firstWeekStart(user, year)).Hours() / 24)
+		return periodKey{Year: year, Week: days/7 + 1}
+	case model.BudgetPeriodYearly:
+		year := date.Year()
+		if start, _ := periodRange(user, periodKey{Year: year}); date.Before(start) {
+			year--
+		}
+		return periodKey{Year: year}
+	default:
+		if date.Day() < user.PeriodStartDay {
+			date = date.AddDate(0, -1, 0)
+		}
+		return periodKey{Year: date.Year(), Month: int(date.Month())}
+	}
+}
+
+// previousPeriod dan nextPeriod bergeser satu periode dari kunci yang diberikan
+func previousPeriod(user model.User, key periodKey) periodKey {
+	start, _ := periodRange(user, key)
+	return periodContaining(user, start.AddDate(0, 0, -1))
+}
+
+func nextPeriod(user model.User, key periodKey) periodKey {
+	_, end := periodRange(user, key)
+	return periodContaining(user, end)
+}
+
+// normalizePeriodKey memvalidasi kombinasi year/month/week sesuai jenis periode user
+func normalizePeriodKey(user model.User, year, month, week int) (periodKey, error) {
+	if year == 0 {
+		return periodKey{}, errors.New("year is required")
+	}
+	switch user.BudgetPeriod {
+	case model.BudgetPeriodWeekly:
+		if week < 1 || week > 53 {
+			return periodKey{}, errors.New("week must be between 1 and 53 for weekly budgets")
+		}
+		// Minggu ke-53 hanya valid jika masih dimulai di tahun yang sama
+		key := periodKey{Year: year, Week: week}
+		if start, _ := periodRange(user, key); start.Year() != year {
+			return periodKey{}, errors.New("week is out of range for this year")
+		}
+		return key, nil
+	case model.BudgetPeriodYearly:
+		return periodKey{Year: year}, nil
+	default:
+		if month < 1 || month > 12 {
+			return periodKey{}, errors.New("month must be between 1 and 12 for monthly budgets")
+		}
+		return periodKey{Year: year, Month: month}, nil
+	}
+}
+
+// periodKeyFromQuery membaca periode dari query ?date=YYYY-MM-DD atau ?year=&month=&week=.
+// Jika tidak ada parameter, periode yang sedang berjalan yang dipakai.
+func periodKeyFromQuery(c *gin.Context, user model.User) (periodKey, error) {
+	if dateStr := c.Query("date"); dateStr != "" {
+		date, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return periodKey{}, errors.New("date must be in YYYY-MM-DD format")
+		}
+		return periodContaining(user, date), nil
+	}
+	year, _ := strconv.Atoi(c.Query("year"))
+	month, _ := strconv.Atoi(c.Query("month"))
+	week, _ := strconv.Atoi(c.Query("week"))
+	if year == 0 && month == 0 && week == 0 {
+		return periodContaining(user, time.Now()), nil
+	}
+	return normalizePeriodKey(user, year, month, week)
+}
+
+// validateBudgetPeriodInput memastikan hari/bulan awal sesuai jenis periode
+func validateBudgetPeriodInput(input *BudgetPeriodInput) error {
+	switch input.BudgetPeriod {
+	case model.BudgetPeriodWeekly:
+		if input.PeriodStartDay < 0 || input.PeriodStartDay > 6 {
+			return errors.New("period_start_day must be between 0 (Sunday) and 6 (Saturday) for weekly periods")
+		}
+		input.PeriodStartMonth = 1
+	case model.BudgetPeriodYearly:
+		if input.PeriodStartDay < 1 || input.PeriodStartDay > 28 {
+			return errors.New("period_start_day must be between 1 and 28 for yearly periods")
+		}
+		if input.PeriodStartMonth == 0 {
+			input.PeriodStartMonth = 1
+		}
+	default:
+		if input.PeriodStartDay < 1 || input.PeriodStartDay > 28 {
+			return errors.New("period_start_day must be between 1 and 28 for monthly periods")
+		}
+		input.PeriodStartMonth = 1
+	}
+	return nil
+}
+
+// --- Handler untuk Melihat Pengaturan Periode Budget ---
+func GetBudgetPeriodSettings(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	c.JSON(http.StatusOK, BudgetPeriodInput{
+		BudgetPeriod:     currentUser.BudgetPeriod,
+		PeriodStartDay:   currentUser.PeriodStartDay,
+		PeriodStartMonth: currentUser.PeriodStartMonth,
+	})
+}
+
+// --- Handler untuk Mengubah Pengaturan Periode Budget ---
+func UpdateBudgetPeriodSettings(c *gin.Context) {
+	var input BudgetPeriodInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateBudgetPeriodInput(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentUser := c.MustGet("currentUser").(model.User)
+	err := database.DB.Model(&currentUser).Updates(map[string]interface{}{
+		"budget_period":      input.BudgetPeriod,
+		"period_start_day":   input.PeriodStartDay,
+		"period_start_month": input.PeriodStartMonth,
+	}).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update budget period settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+// --- Handler untuk Mendapatkan Rentang Tanggal sebuah Periode ---
+func GetBudgetPeriod(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	key, err := periodKeyFromQuery(c, currentUser)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	start, end := periodRange(currentUser, key)
+	c.JSON(http.StatusOK, PeriodResponse{periodKey: key, Start: start, End: end})
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ReconciliationInput struct {
+	StatementDate    time.Time `json:"statement_date" binding:"required"`
+	StatementBalance *float64  `json:"statement_balance" binding:"required"`
+}
+
+type ReconciliationSummary struct {
+	Reconciliation model.Reconciliation `json:"reconciliation"`
+	ClearedBalance float64              `json:"cleared_balance"`
+	Difference     float64              `json:"difference"` // statement_balance - cleared_balance
+	Transactions   []model.Transaction  `json:"transactions"`
+}
+
+// buildReconciliationSummary menghitung saldo cleared & reconciled sampai tanggal
+// rekening koran dan daftar transaksi yang masih bisa dicentang pada sesi ini.
+func buildReconciliationSummary(db *gorm.DB, reconciliation model.Reconciliation) (ReconciliationSummary, error) {
+	summary := ReconciliationSummary{Reconciliation: reconciliation, Transactions: []model.Transaction{}}
+	statementEnd := reconciliation.StatementDate.AddDate(0, 0, 1)
+
+	var cleared []model.Transaction
+	if err := accountTransactions(db, reconciliation.AccountID).
+		Where("status IN ? AND transaction_date < ?", []string{model.TransactionStatusCleared, model.TransactionStatusReconciled}, statementEnd).
+		Find(&cleared).Error; err != nil {
+		return summary, err
+	}
+	for _, transaction := range cleared {
+		summary.ClearedBalance += ledger.Delta(transaction, reconciliation.AccountID)
+	}
+	summary.ClearedBalance = roundMoney(summary.ClearedBalance)
+	summary.Difference = roundMoney(reconciliation.StatementBalance - summary.ClearedBalance)
+
+	if err := accountTransactions(db.Preload("SubCategory"), reconciliation.AccountID).
+		Where("status <> ? AND transaction_date < ?", model.TransactionStatusReconciled, statementEnd).
+		Order("transaction_date asc, id asc").Find(&summary.Transactions).Error; err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// findReconciliation mengambil sesi rekonsiliasi milik user
+func findReconciliation(c *gin.Context) (model.Reconciliation, bool) {
+	var reconciliation model.Reconciliation
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return reconciliation, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&reconciliation).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reconciliation not found"})
+		return reconciliation, false
+	}
+	return reconciliation, true
+}
+
+// --- Handler untuk Memulai Sesi Rekonsiliasi Akun ---
+func StartReconciliation(c *gin.Context) {
+	accountID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input ReconciliationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var account model.Account
+	if err := database.DB.Where("id = ? AND user_id = ?", accountID, currentUser.ID).First(&account).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Account not found"})
+		return
+	}
+
+	// Hanya boleh ada satu sesi terbuka per akun
+	var openCount int64
+	database.DB.Model(&model.Reconciliation{}).Where("account_id = ? AND status = ?", account.ID, model.ReconciliationStatusOpen).Count(&openCount)
+	if openCount > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "An open reconciliation already exists for this account"})
+		return
+	}
+
+	statementDate := input.StatementDate
+	reconciliation := model.Reconciliation{
+		UserID:           currentUser.ID,
+		AccountID:        account.ID,
+		StatementDate:    time.Date(statementDate.Year(), statementDate.Month(), statementDate.Day(), 0, 0, 0, 0, time.UTC),
+		StatementBalance: *input.StatementBalance,
+		Status:           model.ReconciliationStatusOpen,
+	}
+	if err := database.DB.Create(&reconciliation).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start reconciliation"})
+		return
+	}
+
+	summary, err := buildReconciliationSummary(database.DB, reconciliation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build reconciliation summary"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// --- Handler untuk Melihat Sesi Rekonsiliasi (selisih dihitung langsung) ---
+func GetReconciliation(c *gin.Context) {
+	reconciliation, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	summary, err := buildReconciliationSummary(database.DB, reconciliation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build reconciliation summary"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// --- Handler untuk Mencentang/Menghapus Centang Transaksi (pending <-> cleared) ---
+func ToggleReconciliationTransaction(c *gin.Context) {
+	reconciliation, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	if reconciliation.Status != model.ReconciliationStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reconciliation is already completed"})
+		return
+	}
+	transactionID, err := strconv.Atoi(c.Param("transaction_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid transaction ID"})
+		return
+	}
+
+	var transaction model.Transaction
+	if err := accountTransactions(database.DB, reconciliation.AccountID).
+		Where("id = ? AND user_id = ?", transactionID, reconciliation.UserID).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found in this account"})
+		return
+	}
+	if transaction.Status == model.TransactionStatusReconciled {
+		c.JSON(http.StatusConflict, gin.H{"error": errLockedTransaction.Error()})
+		return
+	}
+
+	newStatus := model.TransactionStatusCleared
+	if transaction.Status == model.TransactionStatusCleared {
+		newStatus = model.TransactionStatusPending
+	}
+	// Transaksi setelah tanggal statement tidak ikut dikunci saat rekonsiliasi selesai
+	if newStatus == model.TransactionStatusCleared && !transaction.TransactionDate.Before(reconciliation.StatementDate.AddDate(0, 0, 1)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction is dated after the statement date and cannot be cleared in this reconciliation"})
+		return
+	}
+	before := transaction
+	if err := database.DB.Model(&transaction).Update("status", newStatus).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction"})
+		return
+	}
+	publishEvent(reconciliation.UserID, webhook.EventTransactionUpdated, gin.H{"before": before, "after": transaction})
+
+	summary, err := buildReconciliationSummary(database.DB, reconciliation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build reconciliation summary"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// --- Handler untuk Menyelesaikan Rekonsiliasi dan Mengunci Transaksi ---
+func FinishReconciliation(c *gin.Context) {
+	reconciliation, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	if reconciliation.Status != model.ReconciliationStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Reconciliation is already completed"})
+		return
+	}
+
+	var locked []model.Transaction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		summary, err := buildReconciliationSummary(tx, reconciliation)
+		if err != nil {
+			return err
+		}
+		if summary.Difference != 0 {
+			return errors.New("cleared balance does not match statement balance, difference is " + strconv.FormatFloat(summary.Difference, 'f', 2, 64))
+		}
+		statementEnd := reconciliation.StatementDate.AddDate(0, 0, 1)
+		if err := accountTransactions(tx, reconciliation.AccountID).
+			Where("status = ? AND transaction_date < ?", model.TransactionStatusCleared, statementEnd).
+			Find(&locked).Error; err != nil {
+			return err
+		}
+		if err := accountTransactions(tx.Model(&model.Transaction{}), reconciliation.AccountID).
+			Where("status = ? AND transaction_date < ?", model.TransactionStatusCleared, statementEnd).
+			Updates(map[string]interface{}{"status": model.TransactionStatusReconciled, "reconciliation_id": reconciliation.ID}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		reconciliation.Status = model.ReconciliationStatusCompleted
+		reconciliation.CompletedAt = &now
+		return tx.Save(&reconciliation).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	publishTransactionsUpdated(database.DB, reconciliation.UserID, locked)
+	c.JSON(http.StatusOK, reconciliation)
+}
+
+// --- Handler untuk Membatalkan Sesi Rekonsiliasi yang Masih Terbuka ---
+func CancelReconciliation(c *gin.Context) {
+	reconciliation, ok := findReconciliation(c)
+	if !ok {
+		return
+	}
+	if reconciliation.Status != model.ReconciliationStatusOpen {
+		c.JSON(http.StatusConflict, gin.H{"error": "Completed reconciliations cannot be cancelled"})
+		return
+	}
+	database.DB.Delete(&reconciliation)
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation cancelled successfully"})
+}
+
+// --- Handler untuk Membuka Kunci Transaksi yang Sudah Direkonsiliasi (reconciled -> cleared) ---
+func UnlockTransaction(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var transaction model.Transaction
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if transaction.Status != model.TransactionStatusReconciled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction is not locked"})
+		return
+	}
+
+	before := transaction
+	if err := database.DB.Model(&transaction).Update("status", model.TransactionStatusCleared).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock transaction"})
+		return
+	}
+	publishEvent(currentUser.ID, webhook.EventTransactionUpdated, gin.H{"before": before, "after": transaction})
+	c.JSON(http.StatusOK, gin.H{"message": "Transaction unlocked successfully"})
+}
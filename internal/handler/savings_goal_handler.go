@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type SavingsGoalInput struct {
+	Name         string     `json:"name" binding:"required,max=255"`
+	TargetAmount float64    `json:"target_amount" binding:"required,gt=0"`
+	TargetDate   *time.Time `json:"target_date"`
+	AccountIDs   []uint     `json:"account_ids" binding:"required,min=1"`
+}
+
+type SavingsContributionInput struct {
+	Type             string     `json:"type" binding:"required,oneof=transfer earmark"`
+	Amount           float64    `json:"amount" binding:"required"`
+	FromAccountID    *uint      `json:"from_account_id"` // wajib untuk transfer
+	AccountID        *uint      `json:"account_id"`      // akun tertaut tujuan, default akun tertaut pertama
+	ContributionDate *time.Time `json:"contribution_date"`
+	Notes            string     `json:"notes"`
+}
+
+type SavingsGoalProgress struct {
+	Goal                        model.SavingsGoal `json:"goal"`
+	SavedAmount                 float64           `json:"saved_amount"`
+	RemainingAmount             float64           `json:"remaining_amount"`
+	ProgressPercent             float64           `json:"progress_percent"`
+	Completed                   bool              `json:"completed"`
+	RequiredMonthlyContribution *float64          `json:"required_monthly_contribution"` // null jika tanpa target_date
+	MonthlyPace                 float64           `json:"monthly_pace"`                  // rata-rata kontribusi per bulan sejak kontribusi pertama
+	ProjectedCompletionDate     *time.Time        `json:"projected_completion_date"`
+	OnTrack                     *bool             `json:"on_track"`
+}
+
+// monthsBetween menghitung selisih bulan (pecahan) antara dua tanggal
+func monthsBetween(from, to time.Time) float64 {
+	return to.Sub(from).Hours() / 24 / (365.25 / 12)
+}
+
+// buildSavingsGoalProgress menghitung progres, kebutuhan kontribusi bulanan dan
+// perkiraan tanggal tercapai berdasarkan laju kontribusi sejauh ini.
+func buildSavingsGoalProgress(goal model.SavingsGoal, now time.Time) SavingsGoalProgress {
+	progress := SavingsGoalProgress{Goal: goal}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var firstContribution *time.Time
+	for i, contribution := range goal.Contributions {
+		progress.SavedAmount += contribution.Amount
+		if firstContribution == nil || contribution.ContributionDate.Before(*firstContribution) {
+			firstContribution = &goal.Contributions[i].ContributionDate
+		}
+	}
+	progress.SavedAmount = roundMoney(progress.SavedAmount)
+	progress.RemainingAmount = roundMoney(math.Max(goal.TargetAmount-progress.SavedAmount, 0))
+	progress.ProgressPercent = roundMoney(progress.SavedAmount / goal.TargetAmount * 100)
+	progress.Completed = progress.RemainingAmount == 0
+
+	if firstContribution != nil && progress.SavedAmount > 0 {
+		// Minimal satu bulan agar kontribusi pertama tidak menghasilkan laju yang berlebihan
+		elapsed := math.Max(monthsBetween(*firstContribution, today), 1)
+		progress.MonthlyPace = roundMoney(progress.SavedAmount / elapsed)
+	}
+
+	if goal.TargetDate != nil && !progress.Completed {
+		monthsLeft := math.Max(math.Ceil(monthsBetween(today, *goal.TargetDate)), 1)
+		required := roundMoney(progress.RemainingAmount / monthsLeft)
+		progress.RequiredMonthlyContribution = &required
+		onTrack := progress.MonthlyPace >= required
+		progress.OnTrack = &onTrack
+	}
+
+	if !progress.Completed && progress.MonthlyPace > 0 {
+		days := int(math.Ceil(progress.RemainingAmount / progress.MonthlyPace * 365.25 / 12))
+		projected := today.AddDate(0, 0, days)
+		progress.ProjectedCompletionDate = &projected
+	}
+	return progress
+}
+
+// findSavingsGoal mengambil target tabungan milik user beserta akun dan kontribusinya
+func findSavingsGoal(c *gin.Context) (model.SavingsGoal, bool) {
+	var goal model.SavingsGoal
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return goal, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Preload("Accounts").Preload("Contributions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("contribution_date asc, id asc")
+	}).Where("id = ? AND user_id = ?", id, currentUser.ID).First(&goal).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Savings goal not found"})
+		return goal, false
+	}
+	return goal, true
+}
+
+func findGoalAccounts(userID uint, accountIDs []uint) ([]model.Account, error) {
+	var accounts []model.Account
+	if err := database.DB.Where("id IN ? AND user_id = ?", accountIDs, userID).Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	if len(accounts) != len(uniqueIDs(accountIDs)) {
+		return nil, errors.New("one or more accounts not found")
+	}
+	return accounts, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	result := []uint{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// --- Handler untuk Membuat Target Tabungan ---
+func CreateSavingsGoal(c *gin.Context) {
+	var input SavingsGoalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	accounts, err := findGoalAccounts(currentUser.ID, input.AccountIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	goal := model.SavingsGoal{
+		UserID:       currentUser.ID,
+		Name:         input.Name,
+		TargetAmount: input.TargetAmount,
+		TargetDate:   input.TargetDate,
+		Accounts:     accounts,
+	}
+	if err := database.DB.Create(&goal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create savings goal"})
+		return
+	}
+	c.JSON(http.StatusOK, buildSavingsGoalProgress(goal, time.Now()))
+}
+
+// --- Handler untuk Mengambil Semua Target Tabungan beserta Progresnya ---
+func GetSavingsGoals(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	var goals []model.SavingsGoal
+	if err := database.DB.Preload("Accounts").Preload("Contributions").
+		Where("user_id = ?", currentUser.ID).Order("target_date IS NULL, target_date asc, id asc").
+		Find(&goals).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve savings goals"})
+		return
+	}
+
+	now := time.Now()
+	result := []SavingsGoalProgress{}
+	for _, goal := range goals {
+		progress := buildSavingsGoalProgress(goal, now)
+		// Riwayat kontribusi hanya dikirim di endpoint detail
+		progress.Goal.Contributions = nil
+		result = append(result, progress)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// --- Handler untuk Detail Target Tabungan beserta Riwayat Kontribusi ---
+func GetSavingsGoal(c *gin.Context) {
+	goal, ok := findSavingsGoal(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, buildSavingsGoalProgress(goal, time.Now()))
+}
+
+// --- Handler untuk Mengubah Target Tabungan ---
+func UpdateSavingsGoal(c *gin.Context) {
+	goal, ok := findSavingsGoal(c)
+	if !ok {
+		return
+	}
+	var input SavingsGoalInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	accounts, err := findGoalAccounts(goal.UserID, input.AccountIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		goal.Name = input.Name
+		goal.TargetAmount = input.TargetAmount
+		goal.TargetDate = input.TargetDate
+		if err := tx.Omit("Accounts", "Contributions").Save(&goal).Error; err != nil {
+			return err
+		}
+		return tx.Model(&goal).Association("Accounts").Replace(accounts)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update savings goal"})
+		return
+	}
+	goal.Accounts = accounts
+	c.JSON(http.StatusOK, buildSavingsGoalProgress(goal, time.Now()))
+}
+
+// --- Handler untuk Menghapus Target Tabungan (transaksi transfer tetap ada) ---
+func DeleteSavingsGoal(c *gin.Context) {
+	goal, ok := findSavingsGoal(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("savings_goal_id = ?", goal.ID).Delete(&model.SavingsGoalContribution{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&goal).Association("Accounts").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&goal).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete savings goal"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Savings goal deleted successfully"})
+}
+
+// --- Handler untuk Menambah Kontribusi (transfer ke akun tertaut atau earmark) ---
+func AddSavingsContribution(c *gin.Context) {
+	goal, ok := findSavingsGoal(c)
+	if !ok {
+		return
+	}
+	var input SavingsContributionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(goal.Accounts) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Savings goal has no linked accounts"})
+		return
+	}
+
+	// Akun tujuan harus salah satu akun yang ditautkan ke target
+	accountID := goal.Accounts[0].ID
+	if input.AccountID != nil {
+		linked := false
+		for _, account := range goal.Accounts {
+			if account.ID == *input.AccountID {
+				linked = true
+			}
+		}
+		if !linked {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account_id must be one of the goal's linked accounts"})
+			return
+		}
+		accountID = *input.AccountID
+	}
+	contributionDate := time.Now()
+	if input.ContributionDate != nil {
+		contributionDate = *input.ContributionDate
+	}
+	contribution := model.SavingsGoalContribution{
+		SavingsGoalID:    goal.ID,
+		Type:             input.Type,
+		Amount:           roundMoney(input.Amount),
+		ContributionDate: time.Date(contributionDate.Year(), contributionDate.Month(), contributionDate.Day(), 0, 0, 0, 0, time.UTC),
+		AccountID:        &accountID,
+		Notes:            input.Notes,
+	}
+
+	saved := 0.0
+	for _, existing := range goal.Contributions {
+		saved += existing.Amount
+	}
+	if roundMoney(saved+contribution.Amount) < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Withdrawal exceeds the amount saved for this goal"})
+		return
+	}
+
+	var created *model.Transaction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.Type == model.SavingsContributionTransfer {
+			if input.Amount <= 0 {
+				return &transactionError{Message: "transfer contributions must have a positive amount, use an earmark to withdraw"}
+			}
+			if input.FromAccountID == nil {
+				return &transactionError{Message: "from_account_id is required for transfer contributions"}
+			}
+			notes := "Savings goal: " + goal.Name
+			if input.Notes != "" {
+				notes += " - " + input.Notes
+			}
+			transaction, err := createTransaction(tx, goal.UserID, TransactionInput{
+				AccountID:            *input.FromAccountID,
+				Amount:               input.Amount,
+				Type:                 model.TransactionTypeTransfer,
+				Notes:                notes,
+				TransactionDate:      contributionDate,
+				DestinationAccountID: &accountID,
+			})
+			if err != nil {
+				return err
+			}
+			contribution.TransactionID = &transaction.ID
+			created = &transaction
+		}
+		return tx.Create(&contribution).Error
+	})
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+
+	if created != nil {
+		publishEvent(goal.UserID, webhook.EventTransactionCreated, created)
+	}
+	goal.Contributions = append(goal.Contributions, contribution)
+	c.JSON(http.StatusOK, buildSavingsGoalProgress(goal, time.Now()))
+}
+
+// --- Handler untuk Menghapus Kontribusi (transaksi transfernya ikut dihapus) ---
+func DeleteSavingsContribution(c *gin.Context) {
+	goal, ok := findSavingsGoal(c)
+	if !ok {
+		return
+	}
+	contributionID, err := strconv.Atoi(c.Param("contribution_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+		return
+	}
+
+	var contribution model.SavingsGoalContribution
+	if err := database.DB.Where("id = ? AND savings_goal_id = ?", contributionID, goal.ID).First(&contribution).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contribution not found"})
+		return
+	}
+
+	var deleted []model.Transaction
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if contribution.TransactionID != nil {
+			var transactions []model.Transaction
+			if err := tx.Where("id = ? AND user_id = ?", *contribution.TransactionID, goal.UserID).Find(&transactions).Error; err != nil {
+				return err
+			}
+			if err := deleteTransactions(tx, transactions); err != nil {
+				return err
+			}
+			deleted = transactions
+		}
+		return tx.Delete(&contribution).Error
+	})
+	if err != nil {
+		respondTransactionError(c, err)
+		return
+	}
+	for _, transaction := range deleted {
+		publishEvent(goal.UserID, webhook.EventTransactionDeleted, transaction)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Contribution deleted successfully"})
+}
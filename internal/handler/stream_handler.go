@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/realtime"
+	"github.com/gin-gonic/gin"
+)
+
+// Komentar berkala agar proxy tidak menutup koneksi yang sedang diam
+const streamHeartbeat = 25 * time.Second
+
+// Event yang dikirim jika sebagian event sejak Last-Event-ID sudah tidak tersedia
+const streamEventReset = "reset"
+
+func writeStreamEvent(c *gin.Context, event realtime.Event) {
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
+
+// --- Handler untuk Membuat Tiket Stream ---
+// Tiket berumur pendek untuk membuka stream lewat ?ticket=, sehingga JWT tidak perlu dikirim di
+// query string (yang tercatat di access log server dan proxy). Tiket bisa dipakai ulang untuk
+// reconnect selama stream tersambung dan sampai satu menit setelah koneksi terakhir putus.
+func CreateStreamTicket(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	ticket, expiresAt, err := realtime.DefaultTickets.Issue(currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_at": expiresAt})
+}
+
+// --- Handler untuk Stream Perubahan Data (Server-Sent Events) ---
+// Mengirim event transaksi, akun, dan budget milik user secara langsung. Klien yang tersambung
+// ulang mengirim header Last-Event-ID (atau ?last_event_id=) untuk menerima event yang terlewat;
+// jika event tersebut sudah tidak tersedia, event "reset" dikirim agar klien memuat ulang data.
+// Klien browser (EventSource) mengautentikasi dengan ?ticket= dari CreateStreamTicket; jika tiket
+// sudah hangus (401), klien meminta tiket baru dan tersambung dengan ?last_event_id= terakhir.
+func StreamEvents(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var since uint64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		since = parsed
+	}
+
+	subscription, backlog, complete := realtime.Default.Subscribe(currentUser.ID, since)
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", streamEventReset)
+	}
+	for _, event := range backlog {
+		writeStreamEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-subscription.C:
+			if !ok {
+				// Koneksi tertinggal dan diputus broker; klien akan tersambung ulang
+				return
+			}
+			writeStreamEvent(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
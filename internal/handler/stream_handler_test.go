@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/middleware"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// streamBlock adalah satu blok SSE (dipisahkan baris kosong)
+type streamBlock struct {
+	id, event, data, retry string
+}
+
+// useStreamUserDB mengganti database.DB dengan DB dry-run yang selalu menemukan user userID
+func useStreamUserDB(t *testing.T, userID uint) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(127.0.0.1:1)/test?parseTime=true", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("failed to open dry-run database: %v", err)
+	}
+	err = db.Callback().Query().After("gorm:query").Register("test:find_user", func(tx *gorm.DB) {
+		if user, ok := tx.Statement.Dest.(*model.User); ok {
+			user.ID = userID
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	previous := database.DB
+	database.DB = db
+	t.Cleanup(func() { database.DB = previous })
+}
+
+// openStream membuka stream dan mengirim setiap blok SSE ke channel sampai koneksi ditutup
+func openStream(t *testing.T, url, lastEventID string) (<-chan streamBlock, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	request.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		request.Header.Set("Last-Event-ID", lastEventID)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		cancel()
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		cancel()
+		t.Fatalf("expected stream status 200, got %d", response.StatusCode)
+	}
+
+	blocks := make(chan streamBlock, 16)
+	go func() {
+		defer close(blocks)
+		defer response.Body.Close()
+		scanner := bufio.NewScanner(response.Body)
+		var block streamBlock
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				blocks <- block
+				block = streamBlock{}
+				continue
+			}
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				block.id = value
+			case "event":
+				block.event = value
+			case "data":
+				block.data = value
+			case "retry":
+				block.retry = value
+			}
+		}
+	}()
+	return blocks, cancel
+}
+
+func nextBlock(t *testing.T, blocks <-chan streamBlock) streamBlock {
+	t.Helper()
+	select {
+	case block, ok := <-blocks:
+		if !ok {
+			t.Fatal("stream closed unexpectedly")
+		}
+		return block
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for stream event")
+	}
+	return streamBlock{}
+}
+
+func TestStreamReconnectWithTicketReplaysBacklog(t *testing.T) {
+	const userID = 424242
+	useStreamUserDB(t, userID)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/stream/ticket", func(c *gin.Context) { c.Set("currentUser", model.User{ID: userID}) }, CreateStreamTicket)
+	router.GET("/api/events/stream", middleware.StreamTicketMiddleware(), StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/api/stream/ticket", "application/json", nil)
+	if err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	var issued struct {
+		Ticket string `json:"ticket"`
+	}
+	json.NewDecoder(response.Body).Decode(&issued)
+	response.Body.Close()
+	if response.StatusCode != http.StatusCreated || issued.Ticket == "" {
+		t.Fatalf("expected a ticket, got status %d", response.StatusCode)
+	}
+	streamURL := server.URL + "/api/events/stream?ticket=" + issued.Ticket
+
+	// Koneksi pertama menerima event secara langsung
+	blocks, disconnect := openStream(t, streamURL, "")
+	if block := nextBlock(t, blocks); block.retry == "" {
+		t.Fatalf("expected retry hint first, got %+v", block)
+	}
+	first, _ := realtime.Default.Publish(userID, "transaction.created", map[string]int{"id": 1})
+	if block := nextBlock(t, blocks); block.id != strconv.FormatUint(first.ID, 10) {
+		t.Fatalf("expected event %d, got %+v", first.ID, block)
+	}
+
+	// Koneksi putus, event berikutnya terjadi selama klien belum tersambung
+	disconnect()
+	missed, _ := realtime.Default.Publish(userID, "transaction.deleted", map[string]int{"id": 1})
+
+	// EventSource tersambung ulang ke URL yang sama dengan Last-Event-ID
+	blocks, disconnect = openStream(t, streamURL, strconv.FormatUint(first.ID, 10))
+	defer disconnect()
+	if block := nextBlock(t, blocks); block.retry == "" {
+		t.Fatalf("expected retry hint first, got %+v", block)
+	}
+	block := nextBlock(t, blocks)
+	if block.id != strconv.FormatUint(missed.ID, 10) || block.event != "transaction.deleted" {
+		t.Fatalf("expected missed event %d to be replayed, got %+v", missed.ID, block)
+	}
+
+	unknown, err := http.Get(server.URL + "/api/events/stream?ticket=unknown")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	unknown.Body.Close()
+	if unknown.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown ticket, got %d", unknown.StatusCode)
+	}
+}
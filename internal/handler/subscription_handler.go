@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/recurring"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Jangka waktu riwayat transaksi yang dipindai untuk mendeteksi langganan
+const subscriptionLookbackMonths = 18
+
+type ConfirmSubscriptionInput struct {
+	CreateBill bool `json:"create_bill"` // buat tagihan dari langganan agar muncul di kalender tagihan
+}
+
+// Angka & tanda baca di catatan (mis. "Netflix 03/2024") diabaikan saat mengelompokkan
+var subscriptionNotesCleaner = regexp.MustCompile(`[^\p{L}\s]+`)
+
+// subscriptionKey menentukan kelompok transaksi: per payee jika ada, selain itu per catatan
+func subscriptionKey(transaction model.Transaction) (string, string) {
+	if transaction.PayeeID != nil {
+		return "payee:" + strconv.Itoa(int(*transaction.PayeeID)), transaction.Payee.Name
+	}
+	normalized := strings.Join(strings.Fields(strings.ToLower(subscriptionNotesCleaner.ReplaceAllString(transaction.Notes, " "))), " ")
+	if normalized == "" {
+		return "", ""
+	}
+	// Dipotong per rune agar karakter multi-byte tidak terbelah
+	if runes := []rune(normalized); len(runes) > 200 {
+		normalized = string(runes[:200])
+	}
+	return "notes:" + normalized, strings.TrimSpace(transaction.Notes)
+}
+
+// DetectSubscriptions memindai pengeluaran user dan menyimpan pola berulang sebagai usulan
+// langganan. Langganan yang sudah ada diperbarui tanpa mengubah statusnya, sehingga yang
+// sudah ditolak tidak muncul lagi. Pola yang tagihannya sudah lewat lebih dari satu
+// periode dianggap berhenti dan tidak diusulkan.
+func DetectSubscriptions(db *gorm.DB, userID uint, now time.Time) ([]model.DetectedSubscription, error) {
+	today := truncateDate(now)
+	var transactions []model.Transaction
+	if err := db.Preload("Payee").
+		Where("user_id = ? AND type = ? AND transaction_date >= ?", userID, model.TransactionTypeExpense, today.AddDate(0, -subscriptionLookbackMonths, 0)).
+		Order("transaction_date asc").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		name    string
+		latest  model.Transaction
+		charges []recurring.Charge
+	}
+	groups := make(map[string]*group)
+	keys := []string{}
+	for _, transaction := range transactions {
+		key, name := subscriptionKey(transaction)
+		if key == "" {
+			continue
+		}
+		if groups[key] == nil {
+			groups[key] = &group{name: name}
+			keys = append(keys, key)
+		}
+		groups[key].latest = transaction
+		groups[key].charges = append(groups[key].charges, recurring.Charge{Date: transaction.TransactionDate, Amount: transaction.Amount})
+	}
+
+	detected := []model.DetectedSubscription{}
+	for _, key := range keys {
+		group := groups[key]
+		pattern, ok := recurring.Detect(group.charges)
+		if !ok || recurring.NextDate(pattern.NextExpectedDate, pattern.Frequency).Before(today) {
+			continue
+		}
+
+		subscription := model.DetectedSubscription{UserID: userID, Key: key, Status: model.SubscriptionStatusSuggested}
+		err := db.Where("user_id = ? AND `key` = ?", userID, key).First(&subscription).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		subscription.Name = group.name
+		subscription.PayeeID = group.latest.PayeeID
+		subscription.AccountID = &group.latest.AccountID
+		subscription.SubCategoryID = group.latest.SubCategoryID
+		subscription.Frequency = pattern.Frequency
+		subscription.Occurrences = pattern.Occurrences
+		subscription.AverageAmount = pattern.AverageAmount
+		subscription.AnnualCost = pattern.AnnualCost
+		subscription.LastChargeDate = pattern.LastChargeDate
+		subscription.NextExpectedDate = pattern.NextExpectedDate
+		if err := db.Save(&subscription).Error; err != nil {
+			return nil, err
+		}
+		if subscription.Status != model.SubscriptionStatusDismissed {
+			detected = append(detected, subscription)
+		}
+	}
+	return detected, nil
+}
+
+// StartSubscriptionDetection menjalankan DetectSubscriptions untuk semua user secara berkala di background
+func StartSubscriptionDetection(interval time.Duration) {
+	go func() {
+		for {
+			var userIDs []uint
+			if err := database.DB.Model(&model.User{}).Pluck("id", &userIDs).Error; err != nil {
+				log.Println("Failed to list users for subscription detection:", err)
+			}
+			for _, userID := range userIDs {
+				if _, err := DetectSubscriptions(database.DB, userID, time.Now()); err != nil {
+					log.Println("Failed to detect subscriptions:", err)
+				}
+			}
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// findSubscription mengambil langganan terdeteksi milik user
+func findSubscription(c *gin.Context) (model.DetectedSubscription, bool) {
+	var subscription model.DetectedSubscription
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return subscription, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&subscription).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return subscription, false
+	}
+	return subscription, true
+}
+
+// --- Handler untuk Mengambil Langganan (opsional ?status=suggested|confirmed|dismissed) ---
+// Tanpa status, langganan yang ditolak tidak ditampilkan.
+func GetSubscriptions(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	query := database.DB.Where("user_id = ?", currentUser.ID)
+	switch status := c.Query("status"); status {
+	case "":
+		query = query.Where("status <> ?", model.SubscriptionStatusDismissed)
+	case model.SubscriptionStatusSuggested, model.SubscriptionStatusConfirmed, model.SubscriptionStatusDismissed:
+		query = query.Where("status = ?", status)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be suggested, confirmed or dismissed"})
+		return
+	}
+
+	var subscriptions []model.DetectedSubscription
+	if err := query.Order("annual_cost desc").Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions"})
+		return
+	}
+	annualCost := 0.0
+	for _, subscription := range subscriptions {
+		if subscription.Status == model.SubscriptionStatusConfirmed {
+			annualCost += subscription.AnnualCost
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"subscriptions":         subscriptions,
+		"confirmed_annual_cost": roundMoney(annualCost),
+	})
+}
+
+// --- Handler untuk Menjalankan Deteksi Langganan Sekarang ---
+func RunSubscriptionDetection(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	subscriptions, err := DetectSubscriptions(database.DB, currentUser.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to detect subscriptions"})
+		return
+	}
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// --- Handler untuk Mengonfirmasi Langganan (opsional membuat tagihan) ---
+func ConfirmSubscription(c *gin.Context) {
+	subscription, ok := findSubscription(c)
+	if !ok {
+		return
+	}
+	var input ConfirmSubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.CreateBill && subscription.BillID == nil {
+			bill := model.Bill{
+				UserID:             subscription.UserID,
+				Name:               subscription.Name,
+				Amount:             subscription.AverageAmount,
+				IsEstimate:         true,
+				Frequency:          subscription.Frequency,
+				Interval:           1,
+				StartDate:          subscription.NextExpectedDate,
+				AccountID:          subscription.AccountID,
+				SubCategoryID:      subscription.SubCategoryID,
+				ReminderDaysBefore: 3,
+				Active:             true,
+			}
+			// Tagihan tidak mengenal frekuensi kuartalan, dipetakan ke bulanan tiap 3 bulan
+			if subscription.Frequency == recurring.FrequencyQuarterly {
+				bill.Frequency = model.BillFrequencyMonthly
+				bill.Interval = 3
+			}
+			if err := createBill(tx, &bill); err != nil {
+				return err
+			}
+			subscription.BillID = &bill.ID
+		}
+		subscription.Status = model.SubscriptionStatusConfirmed
+		return tx.Save(&subscription).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to confirm subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
+
+// --- Handler untuk Menolak Usulan Langganan ---
+func DismissSubscription(c *gin.Context) {
+	subscription, ok := findSubscription(c)
+	if !ok {
+		return
+	}
+	subscription.Status = model.SubscriptionStatusDismissed
+	if err := database.DB.Save(&subscription).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to dismiss subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, subscription)
+}
@@ -2,22 +2,264 @@ package handler
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"time"
 	"strconv"
+	"strings"
 	"github.com/gin-gonic/gin"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database" 
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/ledger"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"   
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
 	"gorm.io/gorm"
 )
+
+// Kode error transaksi yang stabil agar klien tidak perlu mencocokkan teks pesan
+const (
+	ErrCodeTransactionLocked       = "TRANSACTION_LOCKED"
+	ErrCodeSubCategoryRequired     = "SUB_CATEGORY_REQUIRED"
+	ErrCodeSubCategoryNotFound     = "SUB_CATEGORY_NOT_FOUND"
+	ErrCodeSubCategoryTypeMismatch = "SUB_CATEGORY_TYPE_MISMATCH"
+	ErrCodeCategoryTypeInUse       = "CATEGORY_TYPE_IN_USE"
+)
+
+type transactionError struct {
+	Code    string
+	Message string
+}
+
+func (e *transactionError) Error() string {
+	return e.Message
+}
+
+// Transaksi yang sudah direkonsiliasi harus di-unlock dulu sebelum diubah/dihapus
+var errLockedTransaction error = &transactionError{Code: ErrCodeTransactionLocked, Message: "transaction is reconciled and locked, unlock it first"}
+
+// respondTransactionError mengirim error validasi beserta kodenya jika tersedia. Error lain
+// berasal dari database sehingga dikirim sebagai 500 tanpa detail.
+func respondTransactionError(c *gin.Context, err error) {
+	var txErr *transactionError
+	if errors.As(err, &txErr) {
+		response := gin.H{"error": txErr.Message}
+		if txErr.Code != "" {
+			response["code"] = txErr.Code
+		}
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	log.Println("Transaction operation failed:", err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process transaction"})
+}
+
+// validateSubCategory memastikan sub-kategori milik user dan tipe kategorinya sesuai
+// dengan tipe transaksi. Transfer tidak memakai sub-kategori.
+func validateSubCategory(tx *gorm.DB, userID uint, subCategoryID *uint, transactionType string) error {
+	if transactionType == model.TransactionTypeTransfer {
+		return nil
+	}
+	if subCategoryID == nil {
+		return &transactionError{Code: ErrCodeSubCategoryRequired, Message: "sub_category_id is required for " + transactionType}
+	}
+	var subCategory model.SubCategory
+	if err := tx.Preload("Category").Where("id = ? AND user_id = ?", *subCategoryID, userID).First(&subCategory).Error; err != nil {
+		return &transactionError{Code: ErrCodeSubCategoryNotFound, Message: "sub-category not found"}
+	}
+	if subCategory.Category.Type != transactionType {
+		return &transactionError{Code: ErrCodeSubCategoryTypeMismatch, Message: "sub-category belongs to a " + subCategory.Category.Type + " category and cannot be used for " + transactionType}
+	}
+	return nil
+}
+
 type TransactionInput struct {
-	AccountID            uint      `json:"account_id" binding:"required"`
+	AccountID            uint      `json:"account_id"` // boleh kosong jika payee punya akun default
 	SubCategoryID        *uint     `json:"sub_category_id"`
 	Amount               float64   `json:"amount" binding:"required,gt=0"`
 	Type                 string    `json:"type" binding:"required,oneof=expense income transfer"`
 	Notes                string    `json:"notes"`
 	TransactionDate      time.Time `json:"transaction_date" binding:"required"`
 	DestinationAccountID *uint     `json:"destination_account_id"`
+	Status               string    `json:"status" binding:"omitempty,oneof=pending cleared"`
+	PayeeID              *uint     `json:"payee_id"`
+	PayeeName            string    `json:"payee_name"` // payee baru dibuat jika nama belum dikenal
+	InterestAmount       *float64  `json:"interest_amount" binding:"omitempty,gte=0"` // bunga pada pembayaran pinjaman, dihitung otomatis jika kosong
+}
+
+// revertTransaction membatalkan efek saldo transaksi pada semua akun yang terlibat
+func revertTransaction(tx *gorm.DB, transaction model.Transaction) error {
+	accountIDs := []uint{transaction.AccountID}
+	if transaction.DestinationAccountID != nil && *transaction.DestinationAccountID != transaction.AccountID {
+		accountIDs = append(accountIDs, *transaction.DestinationAccountID)
+	}
+	for _, accountID := range accountIDs {
+		delta := ledger.Delta(transaction, accountID)
+		if delta == 0 {
+			continue
+		}
+		if err := tx.Model(&model.Account{}).Where("id = ?", accountID).Update("balance", gorm.Expr("balance - ?", delta)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTransactions menghapus transaksi sekaligus mengembalikan saldo akunnya.
+// Transaksi yang sudah direkonsiliasi menggagalkan seluruh operasi.
+func deleteTransactions(tx *gorm.DB, transactions []model.Transaction) error {
+	for _, transaction := range transactions {
+		if transaction.Status == model.TransactionStatusReconciled {
+			return errLockedTransaction
+		}
+		if err := revertTransaction(tx, transaction); err != nil {
+			return err
+		}
+		if err := deleteTransactionLinks(tx, transaction.ID); err != nil {
+			return err
+		}
+		if err := tx.Delete(&transaction).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteTransactionLinks menghapus data lain yang bergantung pada transaksi yang akan dihapus
+func deleteTransactionLinks(tx *gorm.DB, transactionID uint) error {
+	if err := tx.Where("transaction_id = ?", transactionID).Delete(&model.BillPayment{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("transaction_id = ?", transactionID).Delete(&model.SavingsGoalContribution{}).Error
+}
+
+// checkLinkedTransactionEdit menolak perubahan nominal, jenis, atau akun pada transaksi yang
+// tercatat sebagai pembayaran tagihan atau kontribusi tabungan, agar keduanya tidak menyimpang
+// dari ledger. Catatan, tanggal, kategori, dan payee tetap boleh diubah.
+func checkLinkedTransactionEdit(tx *gorm.DB, transaction model.Transaction, input TransactionInput) error {
+	sameDestination := (transaction.DestinationAccountID == nil && input.DestinationAccountID == nil) ||
+		(transaction.DestinationAccountID != nil && input.DestinationAccountID != nil && *transaction.DestinationAccountID == *input.DestinationAccountID)
+	if transaction.Amount == input.Amount && transaction.Type == input.Type && transaction.AccountID == input.AccountID && sameDestination {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&model.BillPayment{}).Where("transaction_id = ?", transaction.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &transactionError{Message: "transaction is linked to a bill payment, its amount, type and accounts cannot be changed"}
+	}
+	if err := tx.Model(&model.SavingsGoalContribution{}).Where("transaction_id = ?", transaction.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return &transactionError{Message: "transaction is linked to a savings goal contribution, its amount, type and accounts cannot be changed"}
+	}
+	return nil
+}
+
+type TransactionStatusInput struct {
+	Status string `json:"status" binding:"required,oneof=pending cleared reconciled"`
+}
+
+// Transisi status yang boleh dilakukan langsung oleh user. Status reconciled hanya
+// bisa dicapai lewat rekonsiliasi dan dilepas lewat endpoint unlock.
+var allowedStatusTransitions = map[string][]string{
+	model.TransactionStatusPending: {model.TransactionStatusCleared},
+	model.TransactionStatusCleared: {model.TransactionStatusPending},
+}
+
+func canTransitionStatus(from, to string) bool {
+	for _, allowed := range allowedStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// createTransaction memvalidasi input, menerapkan efek saldo ke akun yang terlibat lalu
+// menyimpan transaksinya. Dipakai handler transaksi maupun fitur lain yang mencatat transaksi.
+func createTransaction(tx *gorm.DB, userID uint, input TransactionInput) (model.Transaction, error) {
+	var transaction model.Transaction
+	if err := resolveTransactionPayee(tx, userID, &input); err != nil {
+		return transaction, err
+	}
+	if input.AccountID == 0 {
+		return transaction, &transactionError{Message: "account_id is required"}
+	}
+	var sourceAccount model.Account
+	if err := tx.Where("id = ? AND user_id = ?", input.AccountID, userID).First(&sourceAccount).Error; err != nil {
+		return transaction, &transactionError{Message: "source account not found"}
+	}
+	if sourceAccount.Archived {
+		return transaction, &transactionError{Message: "source account is archived"}
+	}
+	// Sub-kategori pada transfer diabaikan, bukan ditolak, agar klien lama tetap berjalan
+	if input.Type == model.TransactionTypeTransfer {
+		input.SubCategoryID = nil
+	}
+	if err := validateSubCategory(tx, userID, input.SubCategoryID, input.Type); err != nil {
+		return transaction, err
+	}
+	if input.Status == "" {
+		input.Status = model.TransactionStatusPending
+	}
+	transaction = model.Transaction{
+		UserID:          userID,
+		AccountID:       input.AccountID,
+		SubCategoryID:   input.SubCategoryID,
+		Amount:          input.Amount,
+		Type:            input.Type,
+		Notes:           input.Notes,
+		TransactionDate: input.TransactionDate,
+		Status:          input.Status,
+		PayeeID:         input.PayeeID,
+	}
+	switch input.Type {
+	case "expense":
+		sourceAccount.Balance -= input.Amount
+		if err := tx.Save(&sourceAccount).Error; err != nil {
+			return transaction, err
+		}
+	case "income":
+		sourceAccount.Balance += input.Amount
+		if err := tx.Save(&sourceAccount).Error; err != nil {
+			return transaction, err
+		}
+	case "transfer":
+		if input.DestinationAccountID == nil {
+			return transaction, &transactionError{Message: "destination_account_id is required for transfers"}
+		}
+		if input.AccountID == *input.DestinationAccountID {
+			return transaction, &transactionError{Message: "source and destination accounts cannot be the same"}
+		}
+		var destinationAccount model.Account
+		if err := tx.Where("id = ? AND user_id = ?", *input.DestinationAccountID, userID).First(&destinationAccount).Error; err != nil {
+			return transaction, &transactionError{Message: "destination account not found"}
+		}
+		if destinationAccount.Archived {
+			return transaction, &transactionError{Message: "destination account is archived"}
+		}
+		interest, err := loanPaymentInterest(tx, destinationAccount, input.Amount, input.InterestAmount)
+		if err != nil {
+			return transaction, err
+		}
+		sourceAccount.Balance -= input.Amount
+		destinationAccount.Balance += input.Amount - interest
+		transaction.DestinationAccountID = input.DestinationAccountID
+		transaction.InterestAmount = interest
+		if err := tx.Save(&sourceAccount).Error; err != nil {
+			return transaction, err
+		}
+		if err := tx.Save(&destinationAccount).Error; err != nil {
+			return transaction, err
+		}
+	default:
+		return transaction, &transactionError{Message: "invalid transaction type"}
+	}
+	if err := tx.Create(&transaction).Error; err != nil {
+		return transaction, err
+	}
+	return transaction, nil
 }
 
 func CreateTransaction(c *gin.Context) {
@@ -27,65 +269,15 @@ func CreateTransaction(c *gin.Context) {
 		return
 	}
 	currentUser := c.MustGet("currentUser").(model.User)
+	var created model.Transaction
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		var sourceAccount model.Account
-		if err := tx.Where("id = ? AND user_id = ?", input.AccountID, currentUser.ID).First(&sourceAccount).Error; err != nil {
-			return errors.New("source account not found")
-		}
-		transaction := model.Transaction{
-			UserID:          currentUser.ID,
-			AccountID:       input.AccountID,
-			SubCategoryID:   input.SubCategoryID,
-			Amount:          input.Amount,
-			Type:            input.Type,
-			Notes:           input.Notes,
-			TransactionDate: input.TransactionDate,
-		}
-		switch input.Type {
-		case "expense":
-			if input.SubCategoryID == nil {
-				return errors.New("sub_category_id is required for expenses")
-			}
-			sourceAccount.Balance -= input.Amount
-			if err := tx.Save(&sourceAccount).Error; err != nil {
-				return err
-			}
-		case "income":
-			if input.SubCategoryID == nil {
-				return errors.New("sub_category_id is required for income")
-			}
-			sourceAccount.Balance += input.Amount
-			if err := tx.Save(&sourceAccount).Error; err != nil {
-				return err
-			}
-		case "transfer":
-			if input.DestinationAccountID == nil {
-				return errors.New("destination_account_id is required for transfers")
-			}
-			if input.AccountID == *input.DestinationAccountID {
-				return errors.New("source and destination accounts cannot be the same")
-			}
-			var destinationAccount model.Account
-			if err := tx.Where("id = ? AND user_id = ?", *input.DestinationAccountID, currentUser.ID).First(&destinationAccount).Error; err != nil {
-				return errors.New("destination account not found")
-			}
-			sourceAccount.Balance -= input.Amount
-			destinationAccount.Balance += input.Amount
-			transaction.DestinationAccountID = input.DestinationAccountID
-			if err := tx.Save(&sourceAccount).Error; err != nil {
-				return err
-			}
-			if err := tx.Save(&destinationAccount).Error; err != nil {
-				return err
-			}
-		default:
-			return errors.New("invalid transaction type")
+		transaction, err := createTransaction(tx, currentUser.ID, input)
+		if err != nil {
+			return err
 		}
-		// 1. Buat record transaksi di database.
+		created = transaction
+		// 1. Buat record transaksi di database lewat createTransaction.
 // Setelah ini, variabel `transaction` akan otomatis terisi dengan ID dari database.
-if err := tx.Create(&transaction).Error; err != nil {
-    return err
-}
 
 // 2. Ambil KEMBALI record yang baru saja dibuat tersebut (menggunakan ID-nya)
 // beserta semua data relasinya untuk dikirim sebagai response.
@@ -99,9 +291,11 @@ c.JSON(http.StatusOK, transaction)
 return nil
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondTransactionError(c, err)
 		return
 	}
+	notifyBudgetThresholds(currentUser, created)
+	publishEvent(currentUser.ID, webhook.EventTransactionCreated, created)
 	// c.JSON(http.StatusOK, gin.H{"message": "Transaction created successfully"})
 }
 
@@ -115,6 +309,7 @@ func GetTransactions(c *gin.Context) {
 	query := database.DB.Preload("Account").
 		Preload("SubCategory").
 		Preload("SubCategory.Category").
+		Preload("Payee").
 		Where("user_id = ?", currentUser.ID).
 		Order("transaction_date desc")
 
@@ -133,6 +328,14 @@ func GetTransactions(c *gin.Context) {
 	}
 	// ------------------------------------
 
+	// Filter status, contoh: ?status=pending atau ?status=pending,cleared
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status IN ?", strings.Split(status, ","))
+	}
+	if payeeID := c.Query("payee_id"); payeeID != "" {
+		query = query.Where("payee_id = ?", payeeID)
+	}
+
 	var transactions []model.Transaction
 	if err := query.Find(&transactions).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve transactions"})
@@ -152,6 +355,7 @@ func GetTransactionByID(c *gin.Context) {
 	query := database.DB.Preload("Account").
 		Preload("SubCategory").
 		Preload("SubCategory.Category").
+		Preload("Payee").
 		Where("id = ? AND user_id = ?", id, currentUser.ID).
 		First(&transaction)
 	if err := query.Error; err != nil {
@@ -168,27 +372,32 @@ func DeleteTransaction(c *gin.Context) {
 		return
 	}
 	currentUser := c.MustGet("currentUser").(model.User)
+	var deleted model.Transaction
 	err = database.DB.Transaction(func(tx *gorm.DB) error {
 		var transaction model.Transaction
 		if err := tx.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&transaction).Error; err != nil {
-			return errors.New("transaction not found")
+			return &transactionError{Message: "transaction not found"}
+		}
+		deleted = transaction
+		if transaction.Status == model.TransactionStatusReconciled {
+			return errLockedTransaction
 		}
 		var sourceAccount model.Account
 		if err := tx.First(&sourceAccount, transaction.AccountID).Error; err != nil {
-			return errors.New("source account not found")
+			return &transactionError{Message: "source account not found"}
 		}
 		switch transaction.Type {
 		case "expense":
 			sourceAccount.Balance += transaction.Amount
-		case "income":
+		case "income", model.TransactionTypeOpeningBalance, model.TransactionTypeAdjustment:
 			sourceAccount.Balance -= transaction.Amount
 		case "transfer":
 			var destAccount model.Account
 			if err := tx.First(&destAccount, transaction.DestinationAccountID).Error; err != nil {
-				return errors.New("destination account not found for reversal")
+				return &transactionError{Message: "destination account not found for reversal"}
 			}
 			sourceAccount.Balance += transaction.Amount
-			destAccount.Balance -= transaction.Amount
+			destAccount.Balance -= transaction.Amount - transaction.InterestAmount
 			if err := tx.Save(&destAccount).Error; err != nil {
 				return err
 			}
@@ -196,15 +405,20 @@ func DeleteTransaction(c *gin.Context) {
 		if err := tx.Save(&sourceAccount).Error; err != nil {
 			return err
 		}
+		if err := deleteTransactionLinks(tx, transaction.ID); err != nil {
+			return err
+		}
 		if err := tx.Delete(&transaction).Error; err != nil {
 			return err
 		}
 		return nil
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondTransactionError(c, err)
 		return
 	}
+	notifyBudgetThresholds(currentUser, deleted)
+	publishEvent(currentUser.ID, webhook.EventTransactionDeleted, deleted)
 	c.JSON(http.StatusOK, gin.H{"message": "Transaction deleted successfully"})
 }
 
@@ -225,18 +439,30 @@ func UpdateTransaction(c *gin.Context) {
 
     currentUser := c.MustGet("currentUser").(model.User)
 
+    // Salinan sebelum & sesudah perubahan, untuk memeriksa ambang budget kedua periode/kategori
+    var before, after model.Transaction
     err = database.DB.Transaction(func(tx *gorm.DB) error {
         // 1. Ambil data transaksi LAMA
         var oldTransaction model.Transaction
         if err := tx.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&oldTransaction).Error; err != nil {
-            return errors.New("transaction not found")
+            return &transactionError{Message: "transaction not found"}
+        }
+        before = oldTransaction
+        if oldTransaction.Status == model.TransactionStatusReconciled {
+            return errLockedTransaction
+        }
+        if oldTransaction.Type == model.TransactionTypeOpeningBalance || oldTransaction.Type == model.TransactionTypeAdjustment {
+            return &transactionError{Message: "opening balance and adjustment transactions cannot be edited, update the account balance instead"}
+        }
+        if err := checkLinkedTransactionEdit(tx, oldTransaction, input); err != nil {
+            return err
         }
 
         // 2. KEMBALIKAN SALDO berdasarkan transaksi LAMA (Revert)
         {
             var oldSourceAccount model.Account
             if err := tx.First(&oldSourceAccount, oldTransaction.AccountID).Error; err != nil {
-                return errors.New("old source account not found")
+                return &transactionError{Message: "old source account not found"}
             }
             switch oldTransaction.Type {
             case "expense":
@@ -246,10 +472,10 @@ func UpdateTransaction(c *gin.Context) {
             case "transfer":
                 var oldDestAccount model.Account
                 if err := tx.First(&oldDestAccount, oldTransaction.DestinationAccountID).Error; err != nil {
-                    return errors.New("old destination account not found")
+                    return &transactionError{Message: "old destination account not found"}
                 }
                 oldSourceAccount.Balance += oldTransaction.Amount
-                oldDestAccount.Balance -= oldTransaction.Amount
+                oldDestAccount.Balance -= oldTransaction.Amount - oldTransaction.InterestAmount
                 if err := tx.Save(&oldDestAccount).Error; err != nil {
                     return err
                 }
@@ -260,10 +486,23 @@ func UpdateTransaction(c *gin.Context) {
         }
 
         // 3. TERAPKAN SALDO berdasarkan input BARU (Apply)
+        if err := resolveTransactionPayee(tx, currentUser.ID, &input); err != nil {
+            return err
+        }
+        if input.Type == model.TransactionTypeTransfer {
+            input.SubCategoryID = nil
+        }
+        if err := validateSubCategory(tx, currentUser.ID, input.SubCategoryID, input.Type); err != nil {
+            return err
+        }
+        interestAmount := 0.0
         {
             var newSourceAccount model.Account
             if err := tx.Where("id = ? AND user_id = ?", input.AccountID, currentUser.ID).First(&newSourceAccount).Error; err != nil {
-                return errors.New("new source account not found")
+                return &transactionError{Message: "new source account not found"}
+            }
+            if newSourceAccount.Archived && newSourceAccount.ID != oldTransaction.AccountID {
+                return &transactionError{Message: "new source account is archived"}
             }
             switch input.Type {
             case "expense":
@@ -272,14 +511,25 @@ func UpdateTransaction(c *gin.Context) {
                 newSourceAccount.Balance += input.Amount
             case "transfer":
                 if input.DestinationAccountID == nil {
-                    return errors.New("destination_account_id is required")
+                    return &transactionError{Message: "destination_account_id is required"}
+                }
+                if input.AccountID == *input.DestinationAccountID {
+                    return &transactionError{Message: "source and destination accounts cannot be the same"}
                 }
                 var newDestAccount model.Account
                 if err := tx.Where("id = ? AND user_id = ?", *input.DestinationAccountID, currentUser.ID).First(&newDestAccount).Error; err != nil {
-                    return errors.New("new destination account not found")
+                    return &transactionError{Message: "new destination account not found"}
                 }
+                if newDestAccount.Archived && (oldTransaction.DestinationAccountID == nil || newDestAccount.ID != *oldTransaction.DestinationAccountID) {
+                    return &transactionError{Message: "new destination account is archived"}
+                }
+                interest, err := loanPaymentInterest(tx, newDestAccount, input.Amount, input.InterestAmount)
+                if err != nil {
+                    return err
+                }
+                interestAmount = interest
                 newSourceAccount.Balance -= input.Amount
-                newDestAccount.Balance += input.Amount
+                newDestAccount.Balance += input.Amount - interest
                 if err := tx.Save(&newDestAccount).Error; err != nil {
                     return err
                 }
@@ -297,17 +547,62 @@ func UpdateTransaction(c *gin.Context) {
         oldTransaction.Notes = input.Notes
         oldTransaction.TransactionDate = input.TransactionDate
         oldTransaction.DestinationAccountID = input.DestinationAccountID
+        oldTransaction.PayeeID = input.PayeeID
+        oldTransaction.InterestAmount = interestAmount
+        if input.Status != "" {
+            oldTransaction.Status = input.Status
+        }
         if err := tx.Save(&oldTransaction).Error; err != nil {
             return err
         }
+        after = oldTransaction
 
         return nil
     })
 
     if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+        respondTransactionError(c, err)
         return
     }
+    notifyBudgetThresholds(currentUser, before, after)
+    publishEvent(currentUser.ID, webhook.EventTransactionUpdated, gin.H{"before": before, "after": after})
 
     c.JSON(http.StatusOK, gin.H{"message": "Transaction updated successfully"})
+}
+
+// --- Handler untuk Mengubah Status Transaksi (pending <-> cleared) ---
+func UpdateTransactionStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var input TransactionStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	var transaction model.Transaction
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&transaction).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaction not found"})
+		return
+	}
+	if transaction.Status == input.Status {
+		c.JSON(http.StatusOK, transaction)
+		return
+	}
+	if !canTransitionStatus(transaction.Status, input.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot change status from " + transaction.Status + " to " + input.Status})
+		return
+	}
+
+	before := transaction
+	if err := database.DB.Model(&transaction).Update("status", input.Status).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaction status"})
+		return
+	}
+	publishEvent(currentUser.ID, webhook.EventTransactionUpdated, gin.H{"before": before, "after": transaction})
+	c.JSON(http.StatusOK, transaction)
 }
\ No newline at end of file
@@ -0,0 +1,440 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/realtime"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Pengirim webhook yang dipakai aplikasi
+var webhookSender = webhook.NewSender()
+
+type WebhookEndpointInput struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Events      []string `json:"events" binding:"required,min=1"` // nama event atau "*" untuk semua
+	Description string   `json:"description" binding:"max=255"`
+	Active      *bool    `json:"active"`
+}
+
+type WebhookEndpointResponse struct {
+	ID          uint     `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"` // utuh hanya saat dibuat/dirotasi, selain itu disamarkan
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+}
+
+// maskWebhookSecret hanya menyisakan 4 karakter terakhir agar secret bisa dikenali tanpa terbaca
+func maskWebhookSecret(secret string) string {
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// webhookEndpointResponse menyamarkan secret kecuali revealSecret bernilai true. Secret
+// dipakai penerima untuk memverifikasi X-Webhook-Signature, jadi hanya ditampilkan sekali.
+func webhookEndpointResponse(endpoint model.WebhookEndpoint, revealSecret bool) WebhookEndpointResponse {
+	secret := maskWebhookSecret(endpoint.Secret)
+	if revealSecret {
+		secret = endpoint.Secret
+	}
+	return WebhookEndpointResponse{
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Secret:      secret,
+		Events:      strings.Split(endpoint.Events, ","),
+		Description: endpoint.Description,
+		Active:      endpoint.Active,
+	}
+}
+
+// validateWebhookInput memastikan URL http(s) ke alamat publik dan event yang dikenal
+func validateWebhookInput(input WebhookEndpointInput) (string, error) {
+	if err := webhook.ValidateURL(input.URL); err != nil {
+		return "", err
+	}
+	for _, event := range input.Events {
+		if event != "*" && !slices.Contains(webhook.Events, event) {
+			return "", errors.New("Unknown webhook event: " + event)
+		}
+	}
+	events := slices.Compact(slices.Sorted(slices.Values(input.Events)))
+	if slices.Contains(events, "*") {
+		events = []string{"*"}
+	}
+	return strings.Join(events, ","), nil
+}
+
+// subscribedTo memeriksa apakah endpoint melanggan sebuah event
+func subscribedTo(endpoint model.WebhookEndpoint, event string) bool {
+	events := strings.Split(endpoint.Events, ",")
+	return slices.Contains(events, "*") || slices.Contains(events, event)
+}
+
+// recordWebhookAttempt menyimpan satu percobaan pengiriman ke log
+func recordWebhookAttempt(db *gorm.DB, endpoint model.WebhookEndpoint, event webhook.Event, payload string, attempt webhook.Attempt) {
+	delivery := model.WebhookDelivery{
+		EndpointID:   endpoint.ID,
+		EventID:      event.ID,
+		Event:        event.Type,
+		Payload:      payload,
+		Attempt:      attempt.Number,
+		StatusCode:   attempt.StatusCode,
+		ResponseBody: attempt.ResponseBody,
+		Success:      attempt.Success(),
+		DurationMs:   attempt.Duration.Milliseconds(),
+	}
+	if attempt.Err != nil {
+		delivery.Error = attempt.Err.Error()
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		log.Println("Failed to record webhook delivery:", err)
+	}
+}
+
+// publishEvent mengirim event ke stream realtime user dan ke semua endpoint webhook aktif
+// yang melanggannya. Pengiriman webhook (termasuk percobaan ulang) berjalan di background
+// agar tidak menahan response.
+func publishEvent(userID uint, eventType string, data interface{}) {
+	if _, err := realtime.Default.Publish(userID, eventType, data); err != nil {
+		log.Println("Failed to publish realtime event:", err)
+	}
+	if err := deliverWebhookEvent(userID, eventType, data); err != nil {
+		log.Println("Failed to load webhook endpoints:", err)
+	}
+}
+
+// publishTransactionsUpdated mengirim transaction.updated untuk transaksi yang diubah secara
+// massal. before adalah isi transaksi sebelum perubahan; isi sesudahnya dimuat ulang.
+func publishTransactionsUpdated(db *gorm.DB, userID uint, before []model.Transaction) {
+	if len(before) == 0 {
+		return
+	}
+	ids := make([]uint, len(before))
+	for i, transaction := range before {
+		ids[i] = transaction.ID
+	}
+	var after []model.Transaction
+	if err := db.Where("id IN ?", ids).Find(&after).Error; err != nil {
+		log.Println("Failed to load updated transactions:", err)
+		return
+	}
+	afterByID := make(map[uint]model.Transaction, len(after))
+	for _, transaction := range after {
+		afterByID[transaction.ID] = transaction
+	}
+	for _, transaction := range before {
+		if updated, ok := afterByID[transaction.ID]; ok {
+			publishEvent(userID, webhook.EventTransactionUpdated, gin.H{"before": transaction, "after": updated})
+		}
+	}
+}
+
+// publishTransactionsDeleted mengirim transaction.deleted untuk setiap transaksi yang dihapus
+// beserta account.updated untuk akun yang saldonya ikut berubah
+func publishTransactionsDeleted(db *gorm.DB, userID uint, transactions []model.Transaction) {
+	accountIDs := []uint{}
+	for _, transaction := range transactions {
+		publishEvent(userID, webhook.EventTransactionDeleted, transaction)
+		accountIDs = append(accountIDs, transaction.AccountID)
+		if transaction.DestinationAccountID != nil {
+			accountIDs = append(accountIDs, *transaction.DestinationAccountID)
+		}
+	}
+	publishAccountsUpdated(db, userID, accountIDs)
+}
+
+// publishAccountsUpdated mengirim account.updated dengan data terbaru setiap akun
+func publishAccountsUpdated(db *gorm.DB, userID uint, accountIDs []uint) {
+	accountIDs = slices.Compact(slices.Sorted(slices.Values(accountIDs)))
+	if len(accountIDs) == 0 {
+		return
+	}
+	var accounts []model.Account
+	if err := db.Where("id IN ? AND user_id = ?", accountIDs, userID).Find(&accounts).Error; err != nil {
+		log.Println("Failed to load updated accounts:", err)
+		return
+	}
+	for _, account := range accounts {
+		publishEvent(userID, webhook.EventAccountUpdated, account)
+	}
+}
+
+// publishCategoryBudgets mengirim budget.updated berisi budget terbaru satu kategori
+func publishCategoryBudgets(db *gorm.DB, userID, categoryID uint) {
+	if categoryID == 0 {
+		return
+	}
+	var budgets []model.Budget
+	if err := db.Where("user_id = ? AND category_id = ?", userID, categoryID).Find(&budgets).Error; err != nil {
+		log.Println("Failed to load updated budgets:", err)
+		return
+	}
+	if len(budgets) > 0 {
+		publishEvent(userID, webhook.EventBudgetUpdated, gin.H{"budgets": budgets})
+	}
+}
+
+// subscribedWebhookEndpoints mengambil endpoint webhook aktif user yang melanggan eventType
+func subscribedWebhookEndpoints(userID uint, eventType string) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	if err := database.DB.Where("user_id = ? AND active = ?", userID, true).Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(endpoints, func(endpoint model.WebhookEndpoint) bool {
+		return !subscribedTo(endpoint, eventType)
+	}), nil
+}
+
+// deliverWebhookEvent mengirim event ke endpoint webhook aktif user yang melanggannya
+func deliverWebhookEvent(userID uint, eventType string, data interface{}) error {
+	endpoints, err := subscribedWebhookEndpoints(userID, eventType)
+	if err != nil {
+		return err
+	}
+	event := webhook.NewEvent(eventType, data)
+	for _, endpoint := range endpoints {
+		go deliverToEndpoint(database.DB, webhookSender, endpoint, event)
+	}
+	return nil
+}
+
+// deliverToEndpoint mengirim event (dengan percobaan ulang) dan mencatat setiap percobaan
+func deliverToEndpoint(db *gorm.DB, sender *webhook.Sender, endpoint model.WebhookEndpoint, event webhook.Event) bool {
+	payload, _ := json.Marshal(event)
+	return sender.Deliver(endpoint.URL, endpoint.Secret, event, func(attempt webhook.Attempt) {
+		recordWebhookAttempt(db, endpoint, event, string(payload), attempt)
+	})
+}
+
+// WebhookNotificationChannel adalah channel pemberitahuan "webhook": pemberitahuan dikirim
+// sebagai event "notification" yang ditandatangani ke endpoint webhook milik user sendiri
+type WebhookNotificationChannel struct{}
+
+func (WebhookNotificationChannel) Name() string {
+	return "webhook"
+}
+
+// Send mengirim langsung (dengan percobaan ulang) dan baru berhasil jika setidaknya satu endpoint
+// menerima, agar pengingat/peringatan dicoba lagi jika tidak ada yang sampai. Dispatcher hanya
+// dipanggil dari proses background, jadi menunggu pengiriman tidak menahan request.
+func (WebhookNotificationChannel) Send(message notify.Message) error {
+	endpoints, err := subscribedWebhookEndpoints(message.UserID, webhook.EventNotification)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return errors.New("no active webhook endpoint subscribes to notification events")
+	}
+
+	event := webhook.NewEvent(webhook.EventNotification, message)
+	results := make(chan bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		go func() {
+			results <- deliverToEndpoint(database.DB, webhookSender, endpoint, event)
+		}()
+	}
+	delivered := false
+	for range endpoints {
+		if <-results {
+			delivered = true
+		}
+	}
+	if !delivered {
+		return errors.New("no webhook endpoint accepted the notification")
+	}
+	return nil
+}
+
+// findWebhookEndpoint mengambil endpoint webhook milik user
+func findWebhookEndpoint(c *gin.Context) (model.WebhookEndpoint, bool) {
+	var endpoint model.WebhookEndpoint
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return endpoint, false
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+	if err := database.DB.Where("id = ? AND user_id = ?", id, currentUser.ID).First(&endpoint).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook endpoint not found"})
+		return endpoint, false
+	}
+	return endpoint, true
+}
+
+// --- Handler untuk Mendaftarkan Endpoint Webhook ---
+func CreateWebhookEndpoint(c *gin.Context) {
+	var input WebhookEndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := validateWebhookInput(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+		return
+	}
+	currentUser := c.MustGet("currentUser").(model.User)
+
+	endpoint := model.WebhookEndpoint{
+		UserID:      currentUser.ID,
+		URL:         input.URL,
+		Secret:      secret,
+		Events:      events,
+		Description: input.Description,
+		Active:      true,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&endpoint).Error; err != nil {
+			return err
+		}
+		// active=false dilewati GORM saat insert karena ada default kolom
+		if input.Active != nil && !*input.Active {
+			endpoint.Active = false
+			return tx.Model(&endpoint).Update("active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook endpoint"})
+		return
+	}
+	c.JSON(http.StatusOK, webhookEndpointResponse(endpoint, true))
+}
+
+// --- Handler untuk Mengambil Semua Endpoint Webhook ---
+func GetWebhookEndpoints(c *gin.Context) {
+	currentUser := c.MustGet("currentUser").(model.User)
+	var endpoints []model.WebhookEndpoint
+	if err := database.DB.Where("user_id = ?", currentUser.ID).Order("id asc").Find(&endpoints).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhook endpoints"})
+		return
+	}
+	response := make([]WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		response = append(response, webhookEndpointResponse(endpoint, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"endpoints": response, "available_events": webhook.Events})
+}
+
+// --- Handler untuk Mengubah Endpoint Webhook ---
+func UpdateWebhookEndpoint(c *gin.Context) {
+	endpoint, ok := findWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	var input WebhookEndpointInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	events, err := validateWebhookInput(input)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	endpoint.URL = input.URL
+	endpoint.Events = events
+	endpoint.Description = input.Description
+	if input.Active != nil {
+		endpoint.Active = *input.Active
+	}
+	if err := database.DB.Save(&endpoint).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update webhook endpoint"})
+		return
+	}
+	c.JSON(http.StatusOK, webhookEndpointResponse(endpoint, false))
+}
+
+// --- Handler untuk Mengganti Secret Endpoint Webhook ---
+// Secret baru hanya ditampilkan di response ini; secret lama langsung tidak berlaku.
+func RotateWebhookSecret(c *gin.Context) {
+	endpoint, ok := findWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate webhook secret"})
+		return
+	}
+	if err := database.DB.Model(&endpoint).Update("secret", secret).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate webhook secret"})
+		return
+	}
+	endpoint.Secret = secret
+	c.JSON(http.StatusOK, webhookEndpointResponse(endpoint, true))
+}
+
+// --- Handler untuk Menghapus Endpoint Webhook beserta Log Pengirimannya ---
+func DeleteWebhookEndpoint(c *gin.Context) {
+	endpoint, ok := findWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("endpoint_id = ?", endpoint.ID).Delete(&model.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&endpoint).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook endpoint"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook endpoint deleted successfully"})
+}
+
+// --- Handler untuk Log Pengiriman Webhook (100 percobaan terakhir) ---
+func GetWebhookDeliveries(c *gin.Context) {
+	endpoint, ok := findWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	var deliveries []model.WebhookDelivery
+	if err := database.DB.Where("endpoint_id = ?", endpoint.ID).Order("id desc").Limit(100).Find(&deliveries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhook deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// --- Handler untuk Mengirim Event Ping ke Endpoint ---
+// Dikirim sekali tanpa percobaan ulang. Hanya status code dan error yang dikembalikan;
+// isi response penerima tidak diteruskan ke klien.
+func PingWebhookEndpoint(c *gin.Context) {
+	endpoint, ok := findWebhookEndpoint(c)
+	if !ok {
+		return
+	}
+	event := webhook.NewEvent(webhook.EventPing, gin.H{"endpoint_id": endpoint.ID, "message": "pong"})
+	payload, _ := json.Marshal(event)
+	attempt := webhookSender.Send(endpoint.URL, endpoint.Secret, event, 1)
+	recordWebhookAttempt(database.DB, endpoint, event, string(payload), attempt)
+
+	var attemptError *string
+	if attempt.Err != nil {
+		message := attempt.Err.Error()
+		attemptError = &message
+	}
+	c.JSON(http.StatusOK, gin.H{"status_code": attempt.StatusCode, "error": attemptError})
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/database"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/notify"
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/webhook"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// newDeliveryLogDB membuat DB dry-run (tanpa koneksi MySQL) yang mengumpulkan setiap
+// WebhookDelivery yang disimpan
+func newDeliveryLogDB(t *testing.T) (*gorm.DB, *[]model.WebhookDelivery) {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "test:test@tcp(127.0.0.1:1)/test?parseTime=true", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("failed to open dry-run database: %v", err)
+	}
+	deliveries := &[]model.WebhookDelivery{}
+	err = db.Callback().Create().After("gorm:create").Register("test:collect_deliveries", func(tx *gorm.DB) {
+		if delivery, ok := tx.Statement.Dest.(*model.WebhookDelivery); ok {
+			*deliveries = append(*deliveries, *delivery)
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to register callback: %v", err)
+	}
+	return db, deliveries
+}
+
+func TestDeliverToEndpointLogsEveryAttempt(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	db, deliveries := newDeliveryLogDB(t)
+	sender := &webhook.Sender{Client: server.Client(), MaxAttempts: 5, BaseDelay: time.Millisecond}
+	endpoint := model.WebhookEndpoint{ID: 9, UserID: 1, URL: server.URL, Secret: "secret", Events: "*", Active: true}
+	event := webhook.NewEvent(webhook.EventTransactionCreated, map[string]int{"id": 42})
+
+	if !deliverToEndpoint(db, sender, endpoint, event) {
+		t.Fatal("expected delivery to succeed")
+	}
+	if len(*deliveries) != 3 {
+		t.Fatalf("expected 3 delivery log rows, got %d", len(*deliveries))
+	}
+	for i, delivery := range *deliveries {
+		if delivery.EndpointID != endpoint.ID || delivery.EventID != event.ID || delivery.Event != event.Type {
+			t.Fatalf("row %d has wrong endpoint/event: %+v", i, delivery)
+		}
+		if delivery.Attempt != i+1 {
+			t.Fatalf("row %d: expected attempt %d, got %d", i, i+1, delivery.Attempt)
+		}
+		if delivery.Payload == "" {
+			t.Fatalf("row %d has no payload", i)
+		}
+		wantSuccess := i == 2
+		if delivery.Success != wantSuccess {
+			t.Fatalf("row %d: expected success=%v, got %v (status %d)", i, wantSuccess, delivery.Success, delivery.StatusCode)
+		}
+	}
+	if (*deliveries)[0].StatusCode != http.StatusInternalServerError || (*deliveries)[2].StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status codes: %d, %d", (*deliveries)[0].StatusCode, (*deliveries)[2].StatusCode)
+	}
+}
+
+func TestMaskWebhookSecret(t *testing.T) {
+	endpoint := model.WebhookEndpoint{ID: 1, Secret: "abcdef0123456789", Events: "*"}
+	if got := webhookEndpointResponse(endpoint, false).Secret; got != "****6789" {
+		t.Fatalf("expected masked secret, got %q", got)
+	}
+	if got := webhookEndpointResponse(endpoint, true).Secret; got != endpoint.Secret {
+		t.Fatalf("expected full secret, got %q", got)
+	}
+}
+
+func TestWebhookNotificationChannelFailsWithoutEndpoints(t *testing.T) {
+	db, _ := newDeliveryLogDB(t)
+	previous := database.DB
+	database.DB = db
+	defer func() { database.DB = previous }()
+
+	// DB dry-run tidak mengembalikan endpoint apa pun, sehingga channel tidak boleh dihitung berhasil
+	if err := (WebhookNotificationChannel{}).Send(notify.Message{UserID: 1, Kind: notify.KindBudgetThreshold}); err == nil {
+		t.Fatal("expected an error when the user has no webhook endpoints")
+	}
+}
@@ -0,0 +1,32 @@
+// Package ledger berisi aturan bagaimana sebuah transaksi mengubah saldo akun,
+// dipakai untuk menghitung ulang saldo ke masa lalu (riwayat, net worth, dll).
+package ledger
+
+import "github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+
+// Delta mengembalikan perubahan saldo akun accountID akibat transaksi t.
+// Untuk transfer, akun sumber berkurang dan akun tujuan bertambah sebesar nominal
+// dikurangi bagian bunganya (pembayaran pinjaman).
+func Delta(t model.Transaction, accountID uint) float64 {
+	var delta float64
+	if t.AccountID == accountID {
+		switch t.Type {
+		case model.TransactionTypeExpense, model.TransactionTypeTransfer:
+			delta -= t.Amount
+		case model.TransactionTypeIncome, model.TransactionTypeOpeningBalance, model.TransactionTypeAdjustment:
+			delta += t.Amount
+		}
+	}
+	if t.Type == model.TransactionTypeTransfer && t.DestinationAccountID != nil && *t.DestinationAccountID == accountID {
+		delta += t.Amount - t.InterestAmount
+	}
+	return delta
+}
+
+// Revert membatalkan efek transaksi t pada peta saldo per akun.
+func Revert(balances map[uint]float64, t model.Transaction) {
+	balances[t.AccountID] -= Delta(t, t.AccountID)
+	if t.DestinationAccountID != nil && *t.DestinationAccountID != t.AccountID {
+		balances[*t.DestinationAccountID] -= Delta(t, *t.DestinationAccountID)
+	}
+}
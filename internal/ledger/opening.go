@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"gorm.io/gorm"
+)
+
+// BackfillOpeningBalances mencatat transaksi opening_balance (cleared) untuk akun yang dibuat
+// sebelum saldo awal dicatat di ledger, sebesar saldo tersimpan dikurangi efek semua
+// transaksinya dan bertanggal sebelum transaksi pertamanya. Akun yang sudah diproses ditandai
+// OpeningBalanceRecorded sehingga backfill aman dijalankan setiap startup.
+func BackfillOpeningBalances(db *gorm.DB) (int, error) {
+	var accounts []model.Account
+	if err := db.Where("opening_balance_recorded = ?", false).Order("id asc").Find(&accounts).Error; err != nil {
+		return 0, err
+	}
+
+	recorded := 0
+	for _, account := range accounts {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			transactions, err := accountTransactions(tx, account.ID)
+			if err != nil {
+				return err
+			}
+			var ledgerBalance float64
+			hasOpening := false
+			openingDate := account.CreatedAt
+			for _, transaction := range transactions {
+				ledgerBalance += Delta(transaction, account.ID)
+				if transaction.Type == model.TransactionTypeOpeningBalance && transaction.AccountID == account.ID {
+					hasOpening = true
+				}
+				if transaction.TransactionDate.Before(openingDate) {
+					openingDate = transaction.TransactionDate
+				}
+			}
+			if len(transactions) > 0 {
+				openingDate = openingDate.AddDate(0, 0, -1)
+			}
+
+			// Akun yang sudah punya saldo awal di ledger cukup ditandai; selisihnya (jika ada)
+			// tetap dilaporkan oleh Recompute
+			if difference := round(account.Balance - round(ledgerBalance)); !hasOpening && difference != 0 {
+				opening := model.Transaction{
+					UserID:          account.UserID,
+					AccountID:       account.ID,
+					Amount:          difference,
+					Type:            model.TransactionTypeOpeningBalance,
+					Notes:           "Opening balance",
+					TransactionDate: openingDate,
+					Status:          model.TransactionStatusCleared,
+				}
+				if err := tx.Create(&opening).Error; err != nil {
+					return err
+				}
+				recorded++
+			}
+			return tx.Model(&account).Update("opening_balance_recorded", true).Error
+		})
+		if err != nil {
+			return recorded, err
+		}
+	}
+	return recorded, nil
+}
@@ -0,0 +1,118 @@
+package ledger
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"gorm.io/gorm"
+)
+
+// Cara memperbaiki selisih saat recompute
+const (
+	FixNone    = ""        // hanya laporan
+	FixBalance = "balance" // timpa Account.Balance dengan saldo hasil ledger
+	FixLedger  = "ledger"  // catat transaksi adjustment agar ledger sama dengan Account.Balance
+)
+
+type Discrepancy struct {
+	AccountID     uint    `json:"account_id"`
+	UserID        uint    `json:"user_id"`
+	AccountName   string  `json:"account_name"`
+	StoredBalance float64 `json:"stored_balance"`
+	LedgerBalance float64 `json:"ledger_balance"`
+	Difference    float64 `json:"difference"` // stored - ledger
+	Fixed         bool    `json:"fixed"`
+	// Transaksi adjustment yang dicatat oleh fix=ledger
+	AdjustmentTransactionID *uint `json:"adjustment_transaction_id,omitempty"`
+}
+
+// ErrOpeningBalancesMissing dikembalikan oleh fix=balance selama masih ada akun lama yang saldo
+// awalnya belum di-backfill; menimpa saldonya dengan hasil ledger akan menghapus saldo awal itu.
+var ErrOpeningBalancesMissing = errors.New("opening balances of existing accounts have not been backfilled yet")
+
+// accountTransactions mengambil semua transaksi yang mengubah saldo akun
+func accountTransactions(tx *gorm.DB, accountID uint) ([]model.Transaction, error) {
+	var transactions []model.Transaction
+	err := tx.Where("(account_id = ? OR (type = ? AND destination_account_id = ?))", accountID, model.TransactionTypeTransfer, accountID).
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Recompute menghitung ulang saldo setiap akun dari transaksinya dan
+// mengembalikan akun yang saldonya tidak cocok. userID 0 berarti semua user.
+func Recompute(db *gorm.DB, userID uint, fix string) ([]Discrepancy, error) {
+	discrepancies := []Discrepancy{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Order("id asc")
+		if userID != 0 {
+			query = query.Where("user_id = ?", userID)
+		}
+		var accounts []model.Account
+		if err := query.Find(&accounts).Error; err != nil {
+			return err
+		}
+		if fix == FixBalance {
+			for _, account := range accounts {
+				if !account.OpeningBalanceRecorded {
+					return ErrOpeningBalancesMissing
+				}
+			}
+		}
+
+		for _, account := range accounts {
+			transactions, err := accountTransactions(tx, account.ID)
+			if err != nil {
+				return err
+			}
+			var ledgerBalance float64
+			for _, transaction := range transactions {
+				ledgerBalance += Delta(transaction, account.ID)
+			}
+			ledgerBalance = round(ledgerBalance)
+			difference := round(account.Balance - ledgerBalance)
+			if difference == 0 {
+				continue
+			}
+
+			discrepancy := Discrepancy{
+				AccountID:     account.ID,
+				UserID:        account.UserID,
+				AccountName:   account.Name,
+				StoredBalance: account.Balance,
+				LedgerBalance: ledgerBalance,
+				Difference:    difference,
+			}
+			switch fix {
+			case FixBalance:
+				if err := tx.Model(&account).Update("balance", ledgerBalance).Error; err != nil {
+					return err
+				}
+				discrepancy.Fixed = true
+			case FixLedger:
+				adjustment := model.Transaction{
+					UserID:          account.UserID,
+					AccountID:       account.ID,
+					Amount:          difference,
+					Type:            model.TransactionTypeAdjustment,
+					Notes:           "Balance recompute adjustment",
+					TransactionDate: time.Now(),
+					Status:          model.TransactionStatusCleared,
+				}
+				if err := tx.Create(&adjustment).Error; err != nil {
+					return err
+				}
+				discrepancy.AdjustmentTransactionID = &adjustment.ID
+				discrepancy.Fixed = true
+			}
+			discrepancies = append(discrepancies, discrepancy)
+		}
+		return nil
+	})
+	return discrepancies, err
+}
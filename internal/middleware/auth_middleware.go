@@ -11,6 +11,7 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/database" 
 	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"   
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/realtime"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
@@ -77,4 +78,34 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Lanjutkan ke handler berikutnya
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// StreamTicketMiddleware mengautentikasi stream realtime memakai tiket dari POST /api/stream/ticket
+// (?ticket=), karena EventSource di browser tidak bisa mengirim header Authorization. Tiket tetap
+// terbuka selama stream berjalan sehingga EventSource bisa tersambung ulang ke URL yang sama.
+// Tanpa tiket, autentikasi jatuh ke AuthMiddleware biasa.
+func StreamTicketMiddleware() gin.HandlerFunc {
+	authMiddleware := AuthMiddleware()
+	return func(c *gin.Context) {
+		value := c.Query("ticket")
+		if value == "" {
+			authMiddleware(c)
+			return
+		}
+
+		userID, closeTicket, ok := realtime.DefaultTickets.Open(value)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			return
+		}
+		defer closeTicket()
+		var user model.User
+		if err := database.DB.First(&user, userID).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+
+		c.Set("currentUser", user)
+		c.Next()
+	}
+}
@@ -2,35 +2,99 @@ package model
 
 import (
 	"time"
-	// "gorm.io/gorm"
+
+	"gorm.io/gorm"
 )
 
 
+const (
+	BudgetPeriodMonthly = "monthly"
+	BudgetPeriodWeekly  = "weekly"
+	BudgetPeriodYearly  = "yearly"
+)
+
 type User struct {
 	ID           uint   `gorm:"primaryKey"`
 	Name         string `gorm:"size:255;not null"`
 	Email        string `gorm:"size:255;not null;unique"`
 	PasswordHash string `gorm:"size:255;not null"`
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	Language     string `gorm:"size:5;not null;default:'en'"` // bahasa kategori bawaan: en atau id
+	// Pengaturan periode budget: monthly, weekly, atau yearly
+	BudgetPeriod     string `gorm:"size:20;not null;default:'monthly'"`
+	PeriodStartDay   int    `gorm:"not null;default:1"` // tanggal (monthly/yearly) atau hari dalam minggu (weekly, 0 = Minggu)
+	PeriodStartMonth int    `gorm:"not null;default:1"` // hanya untuk yearly
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
+const (
+	AccountTypeCash       = "cash"
+	AccountTypeChecking   = "checking"
+	AccountTypeSavings    = "savings"
+	AccountTypeCreditCard = "credit_card"
+	AccountTypeLoan       = "loan"
+	AccountTypeInvestment = "investment"
+	AccountTypeEWallet    = "e_wallet"
+)
+
+// Akun liabilitas (kartu kredit & pinjaman) menyimpan saldo negatif saat ada utang.
 type Account struct {
-	ID        uint      `gorm:"primaryKey"`
-	UserID    uint      `gorm:"not null"`
-	User      User      `gorm:"foreignKey:UserID"`
-	Name      string    `gorm:"size:255;not null"`
-	Balance   float64   `gorm:"type:decimal(15,2);not null;default:0.00"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          uint     `gorm:"primaryKey"`
+	UserID      uint     `gorm:"not null"`
+	User        User     `gorm:"foreignKey:UserID"`
+	Name        string   `gorm:"size:255;not null"`
+	Type        string   `gorm:"size:30;not null;default:'cash'"`
+	Balance     float64  `gorm:"type:decimal(15,2);not null;default:0.00"`
+	CreditLimit *float64 `gorm:"type:decimal(15,2)"` // hanya untuk kartu kredit & pinjaman
+	Archived    bool     `gorm:"not null;default:false"`
+	// Saldo awal sudah tercatat sebagai transaksi opening_balance. Akun yang dibuat sebelum
+	// saldo awal dicatat di ledger diisi oleh backfill saat startup (ledger.BackfillOpeningBalances).
+	OpeningBalanceRecorded bool `gorm:"not null;default:false"`
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+
+	// Dihitung saat dibaca/disimpan, tidak disimpan di database
+	OverCreditLimit bool `gorm:"-"`
+	// Diisi oleh GetAccounts: working = semua transaksi, cleared = tanpa transaksi pending
+	WorkingBalance *float64 `gorm:"-"`
+	ClearedBalance *float64 `gorm:"-"`
+}
+
+// IsLiability menandai akun yang dihitung sebagai utang pada net worth
+func (a Account) IsLiability() bool {
+	return a.Type == AccountTypeCreditCard || a.Type == AccountTypeLoan
+}
+
+func (a *Account) refreshCreditStatus() {
+	a.OverCreditLimit = a.IsLiability() && a.CreditLimit != nil && -a.Balance > *a.CreditLimit
+}
+
+func (a *Account) AfterFind(tx *gorm.DB) error {
+	a.refreshCreditStatus()
+	return nil
+}
+
+func (a *Account) AfterSave(tx *gorm.DB) error {
+	a.refreshCreditStatus()
+	return nil
 }
 
+// Tipe kategori, harus sama dengan tipe transaksi yang memakai sub-kategorinya
+const (
+	CategoryTypeExpense = "expense"
+	CategoryTypeIncome  = "income"
+)
+
 type Category struct {
 	ID        uint      `gorm:"primaryKey"`
 	UserID    uint      `gorm:"not null"`
 	User      User      `gorm:"foreignKey:UserID"`
 	Name      string    `gorm:"size:255;not null"`
 	Type      string    `gorm:"size:50;not null"` 
+	Icon      string    `gorm:"size:50"`
+	Color     string    `gorm:"size:7"` // hex, contoh "#FF8800"
+	SortOrder int       `gorm:"not null;default:0"`
+	Hidden    bool      `gorm:"not null;default:false"`
 	SubCategories []SubCategory `gorm:"foreignKey:CategoryID"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -43,6 +107,10 @@ type SubCategory struct {
 	CategoryID uint     `gorm:"not null"`
 	Category   Category `gorm:"foreignKey:CategoryID"`
 	Name       string   `gorm:"size:255;not null"`
+	Icon       string   `gorm:"size:50"`
+	Color      string   `gorm:"size:7"`
+	SortOrder  int      `gorm:"not null;default:0"`
+	Hidden     bool     `gorm:"not null;default:false"`
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
@@ -62,6 +130,24 @@ type SubCategory struct {
 // 	CreatedAt     time.Time
 // 	UpdatedAt     time.Time
 // }
+
+const (
+	TransactionTypeExpense  = "expense"
+	TransactionTypeIncome   = "income"
+	TransactionTypeTransfer = "transfer"
+	// Dibuat oleh sistem, Amount boleh negatif dan langsung ditambahkan ke saldo akun
+	TransactionTypeOpeningBalance = "opening_balance"
+	TransactionTypeAdjustment     = "adjustment"
+)
+
+// Status transaksi: pending (belum muncul di rekening), cleared (sudah diposting bank),
+// reconciled (sudah dicocokkan dengan rekening koran dan terkunci)
+const (
+	TransactionStatusPending    = "pending"
+	TransactionStatusCleared    = "cleared"
+	TransactionStatusReconciled = "reconciled"
+)
+
 type Transaction struct {
 	ID                   uint `gorm:"primaryKey"`
 	UserID               uint `gorm:"not null"`
@@ -75,19 +161,311 @@ type Transaction struct {
 	Notes                string `gorm:"type:text"`
 	TransactionDate      time.Time `gorm:"not null"`
 	DestinationAccountID *uint // <-- KOLOM BARU DITAMBAHKAN
+	Status               string `gorm:"size:20;not null;default:'pending';index"`
+	ReconciliationID     *uint
+	PayeeID              *uint `gorm:"index"`
+	Payee                Payee `gorm:"foreignKey:PayeeID"`
+	// Bagian bunga dari pembayaran (transfer) ke akun pinjaman, tidak mengurangi pokok
+	InterestAmount       float64 `gorm:"type:decimal(15,2);not null;default:0"`
 	CreatedAt            time.Time
 	UpdatedAt            time.Time
 }
 
+// Payee adalah pihak penerima/pemberi uang (toko, merchant, pemberi kerja, ...).
+// Default sub-kategori dan akun dipakai untuk mengisi otomatis transaksi baru.
+type Payee struct {
+	ID                   uint   `gorm:"primaryKey"`
+	UserID               uint   `gorm:"not null;index"`
+	User                 User   `gorm:"foreignKey:UserID"`
+	Name                 string `gorm:"size:255;not null"`
+	DefaultSubCategoryID *uint
+	DefaultSubCategory   SubCategory `gorm:"foreignKey:DefaultSubCategoryID"`
+	DefaultAccountID     *uint
+	DefaultAccount       Account      `gorm:"foreignKey:DefaultAccountID"`
+	Aliases              []PayeeAlias `gorm:"foreignKey:PayeeID;constraint:OnDelete:CASCADE"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// PayeeAlias adalah nama lain dari payee, misalnya teks yang muncul di mutasi bank
+type PayeeAlias struct {
+	ID      uint   `gorm:"primaryKey"`
+	PayeeID uint   `gorm:"not null;index"`
+	Alias   string `gorm:"size:255;not null"`
+}
+
+// Budget disimpan per periode milik user: Month diisi untuk periode bulanan,
+// Week untuk periode mingguan, dan keduanya 0 untuk periode tahunan.
 type Budget struct {
 	ID         uint     `gorm:"primaryKey"`
-	UserID     uint     `gorm:"not null;uniqueIndex:idx_user_category_month_year"`
+	UserID     uint     `gorm:"not null;uniqueIndex:idx_user_category_period"`
 	User       User     `gorm:"foreignKey:UserID"`
-	CategoryID uint     `gorm:"not null;uniqueIndex:idx_user_category_month_year"`
+	CategoryID uint     `gorm:"not null;uniqueIndex:idx_user_category_period"`
 	Category   Category `gorm:"foreignKey:CategoryID"`
 	Amount     float64  `gorm:"type:decimal(15,2);not null"`
-	Month      int      `gorm:"not null;uniqueIndex:idx_user_category_month_year"`
-	Year       int      `gorm:"not null;uniqueIndex:idx_user_category_month_year"`
+	Month      int      `gorm:"not null;uniqueIndex:idx_user_category_period"`
+	Year       int      `gorm:"not null;uniqueIndex:idx_user_category_period"`
+	Week       int      `gorm:"not null;default:0;uniqueIndex:idx_user_category_period"`
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+}
+
+type BudgetTemplate struct {
+	ID        uint                 `gorm:"primaryKey"`
+	UserID    uint                 `gorm:"not null"`
+	User      User                 `gorm:"foreignKey:UserID"`
+	Name      string               `gorm:"size:255;not null"`
+	Items     []BudgetTemplateItem `gorm:"foreignKey:BudgetTemplateID;constraint:OnDelete:CASCADE"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type BudgetTemplateItem struct {
+	ID               uint     `gorm:"primaryKey"`
+	BudgetTemplateID uint     `gorm:"not null"`
+	CategoryID       uint     `gorm:"not null"`
+	Category         Category `gorm:"foreignKey:CategoryID"`
+	Amount           float64  `gorm:"type:decimal(15,2);not null"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+const (
+	ReconciliationStatusOpen      = "open"
+	ReconciliationStatusCompleted = "completed"
+)
+
+type Reconciliation struct {
+	ID               uint      `gorm:"primaryKey"`
+	UserID           uint      `gorm:"not null"`
+	User             User      `gorm:"foreignKey:UserID"`
+	AccountID        uint      `gorm:"not null;index"`
+	Account          Account   `gorm:"foreignKey:AccountID"`
+	StatementDate    time.Time `gorm:"type:date;not null"`
+	StatementBalance float64   `gorm:"type:decimal(15,2);not null"`
+	Status           string    `gorm:"size:20;not null;default:'open'"`
+	CompletedAt      *time.Time
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// Cara kontribusi ke target tabungan: transfer mencatat transaksi transfer ke akun
+// yang ditautkan, earmark hanya menandai sebagian saldo akun tersebut untuk target ini.
+const (
+	SavingsContributionTransfer = "transfer"
+	SavingsContributionEarmark  = "earmark"
+)
+
+type SavingsGoal struct {
+	ID            uint                      `gorm:"primaryKey"`
+	UserID        uint                      `gorm:"not null;index"`
+	User          User                      `gorm:"foreignKey:UserID"`
+	Name          string                    `gorm:"size:255;not null"`
+	TargetAmount  float64                   `gorm:"type:decimal(15,2);not null"`
+	TargetDate    *time.Time                `gorm:"type:date"`
+	Accounts      []Account                 `gorm:"many2many:savings_goal_accounts"`
+	Contributions []SavingsGoalContribution `gorm:"foreignKey:SavingsGoalID;constraint:OnDelete:CASCADE"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// SavingsGoalContribution bernilai negatif untuk penarikan (hanya lewat earmark)
+type SavingsGoalContribution struct {
+	ID               uint      `gorm:"primaryKey"`
+	SavingsGoalID    uint      `gorm:"not null;index"`
+	Type             string    `gorm:"size:20;not null"`
+	Amount           float64   `gorm:"type:decimal(15,2);not null"`
+	ContributionDate time.Time `gorm:"type:date;not null"`
+	AccountID        *uint
+	TransactionID    *uint  `gorm:"index"`
+	Notes            string `gorm:"type:text"`
+	CreatedAt        time.Time
+}
+
+// LoanDetail menyimpan syarat pinjaman untuk akun bertipe loan. InterestRate adalah
+// bunga tahunan dalam persen, PaymentDay tanggal jatuh tempo setiap bulan.
+type LoanDetail struct {
+	ID           uint      `gorm:"primaryKey"`
+	AccountID    uint      `gorm:"not null;uniqueIndex"`
+	Account      Account   `gorm:"foreignKey:AccountID"`
+	Principal    float64   `gorm:"type:decimal(15,2);not null"`
+	InterestRate float64   `gorm:"type:decimal(7,4);not null;default:0"`
+	TermMonths   int       `gorm:"not null"`
+	StartDate    time.Time `gorm:"type:date;not null"`
+	PaymentDay   int       `gorm:"not null;default:1"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// CreditCardDetail menyimpan siklus tagihan kartu kredit. Tagihan ditutup pada akhir
+// StatementDay dan harus dibayar pada DueDay berikutnya setelah tanggal tutup tagihan.
+type CreditCardDetail struct {
+	ID                    uint    `gorm:"primaryKey"`
+	AccountID             uint    `gorm:"not null;uniqueIndex"`
+	Account               Account `gorm:"foreignKey:AccountID"`
+	StatementDay          int     `gorm:"not null"`
+	DueDay                int     `gorm:"not null"`
+	MinimumPaymentPercent float64 `gorm:"type:decimal(5,2);not null;default:5"`
+	MinimumPaymentAmount  float64 `gorm:"type:decimal(15,2);not null;default:0"` // batas bawah pembayaran minimum
+	InterestRate          float64 `gorm:"type:decimal(7,4);not null;default:0"`  // persen per tahun
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// Aturan jatuh tempo tagihan: StartDate adalah jatuh tempo pertama, lalu berulang
+// setiap Interval minggu/bulan/tahun sampai EndDate (jika diisi).
+const (
+	BillFrequencyOnce    = "once"
+	BillFrequencyWeekly  = "weekly"
+	BillFrequencyMonthly = "monthly"
+	BillFrequencyYearly  = "yearly"
+)
+
+type Bill struct {
+	ID                 uint       `gorm:"primaryKey"`
+	UserID             uint       `gorm:"not null;index"`
+	User               User       `gorm:"foreignKey:UserID"`
+	Name               string     `gorm:"size:255;not null"`
+	Amount             float64    `gorm:"type:decimal(15,2);not null"`
+	IsEstimate         bool       `gorm:"not null;default:false"` // nominal hanya perkiraan (mis. tagihan listrik)
+	Frequency          string     `gorm:"size:20;not null;default:'monthly'"`
+	Interval           int        `gorm:"not null;default:1"`
+	StartDate          time.Time  `gorm:"type:date;not null"`
+	EndDate            *time.Time `gorm:"type:date"`
+	AccountID          *uint
+	Account            Account `gorm:"foreignKey:AccountID"`
+	SubCategoryID      *uint
+	SubCategory        SubCategory `gorm:"foreignKey:SubCategoryID"`
+	ReminderDaysBefore int         `gorm:"not null;default:3"`
+	Active             bool        `gorm:"not null;default:true"`
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// BillPayment menandai satu jatuh tempo tagihan sudah dibayar
+type BillPayment struct {
+	ID            uint      `gorm:"primaryKey"`
+	BillID        uint      `gorm:"not null;uniqueIndex:idx_bill_due_date"`
+	DueDate       time.Time `gorm:"type:date;not null;uniqueIndex:idx_bill_due_date"`
+	Amount        float64   `gorm:"type:decimal(15,2);not null"`
+	TransactionID *uint     `gorm:"index"`
+	CreatedAt     time.Time
+}
+
+// BillReminder mencatat pengingat yang sudah dikirim agar tidak dikirim ulang
+type BillReminder struct {
+	ID      uint      `gorm:"primaryKey"`
+	BillID  uint      `gorm:"not null;uniqueIndex:idx_bill_reminder"`
+	DueDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_bill_reminder"`
+	Kind    string    `gorm:"size:30;not null;uniqueIndex:idx_bill_reminder"`
+	SentAt  time.Time
+}
+
+// Status langganan yang terdeteksi dari riwayat transaksi
+const (
+	SubscriptionStatusSuggested = "suggested"
+	SubscriptionStatusConfirmed = "confirmed"
+	SubscriptionStatusDismissed = "dismissed"
+)
+
+// DetectedSubscription adalah langganan yang ditemukan dari transaksi berulang. Key
+// mengidentifikasi kelompok transaksinya (payee atau catatan) agar deteksi ulang
+// memperbarui baris yang sama dan langganan yang ditolak tidak diusulkan lagi.
+type DetectedSubscription struct {
+	ID               uint   `gorm:"primaryKey"`
+	UserID           uint   `gorm:"not null;uniqueIndex:idx_user_subscription_key"`
+	User             User   `gorm:"foreignKey:UserID"`
+	Key              string `gorm:"size:255;not null;uniqueIndex:idx_user_subscription_key"`
+	Name             string `gorm:"size:255;not null"`
+	PayeeID          *uint  `gorm:"index"`
+	AccountID        *uint
+	SubCategoryID    *uint
+	Frequency        string    `gorm:"size:20;not null"`
+	Occurrences      int       `gorm:"not null"`
+	AverageAmount    float64   `gorm:"type:decimal(15,2);not null"`
+	AnnualCost       float64   `gorm:"type:decimal(15,2);not null"`
+	LastChargeDate   time.Time `gorm:"type:date;not null"`
+	NextExpectedDate time.Time `gorm:"type:date;not null"`
+	Status           string    `gorm:"size:20;not null;default:'suggested';index"`
+	BillID           *uint     // tagihan yang dibuat saat langganan dikonfirmasi
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// AnomalyAlert mencatat pengeluaran tidak biasa yang sudah diberitahukan agar tidak dikirim
+// ulang. Reference menunjuk transaksi ("transaction:12") atau lonjakan per kategori per
+// bulan ("spike:5:2026-10").
+type AnomalyAlert struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;uniqueIndex:idx_user_anomaly_alert"`
+	Kind      string `gorm:"size:30;not null"`
+	Reference string `gorm:"size:100;not null;uniqueIndex:idx_user_anomaly_alert"`
+	SentAt    time.Time
+}
+
+// Notification adalah pemberitahuan di kotak masuk aplikasi
+type Notification struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"not null;index"`
+	User      User   `gorm:"foreignKey:UserID"`
+	Kind      string `gorm:"size:30;not null"`
+	Subject   string `gorm:"size:255;not null"`
+	Body      string `gorm:"type:text"`
+	Data      string `gorm:"type:text"` // data tambahan dalam format JSON
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// NotificationPreference menyalakan/mematikan satu jenis pemberitahuan pada satu channel.
+// Kind "*" berlaku untuk semua jenis; tanpa preferensi, semua channel aktif.
+type NotificationPreference struct {
+	ID      uint   `gorm:"primaryKey"`
+	UserID  uint   `gorm:"not null;uniqueIndex:idx_user_notification_preference"`
+	Kind    string `gorm:"size:30;not null;uniqueIndex:idx_user_notification_preference"`
+	Channel string `gorm:"size:30;not null;uniqueIndex:idx_user_notification_preference"`
+	Enabled bool   `gorm:"not null"`
+}
+
+// BudgetAlert mencatat ambang pemakaian budget (80/100 persen) yang sudah diberitahukan
+// untuk satu kategori pada satu periode, dihapus lagi jika pemakaian turun di bawah ambang
+type BudgetAlert struct {
+	ID         uint `gorm:"primaryKey"`
+	UserID     uint `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	CategoryID uint `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	Year       int  `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	Month      int  `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	Week       int  `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	Threshold  int  `gorm:"not null;uniqueIndex:idx_budget_alert"`
+	SentAt     time.Time
+}
+
+// WebhookEndpoint adalah URL milik user yang menerima event perubahan data.
+// Events berisi daftar event dipisah koma, atau "*" untuk semua event.
+type WebhookEndpoint struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	User        User   `gorm:"foreignKey:UserID"`
+	URL         string `gorm:"size:500;not null"`
+	Secret      string `gorm:"size:64;not null"`
+	Events      string `gorm:"type:text;not null"`
+	Description string `gorm:"size:255"`
+	Active      bool   `gorm:"not null;default:true"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// WebhookDelivery mencatat satu percobaan pengiriman event ke endpoint
+type WebhookDelivery struct {
+	ID           uint   `gorm:"primaryKey"`
+	EndpointID   uint   `gorm:"not null;index"`
+	EventID      string `gorm:"size:64;not null;index"`
+	Event        string `gorm:"size:50;not null"`
+	Payload      string `gorm:"type:text"`
+	Attempt      int    `gorm:"not null"`
+	StatusCode   int
+	ResponseBody string `gorm:"type:text"`
+	Error        string `gorm:"type:text"`
+	Success      bool   `gorm:"not null"`
+	DurationMs   int64
+	CreatedAt    time.Time
 }
\ No newline at end of file
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"encoding/json"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"gorm.io/gorm"
+)
+
+// InboxChannel menyimpan pemberitahuan ke kotak masuk aplikasi (tabel notifications)
+type InboxChannel struct {
+	DB *gorm.DB
+}
+
+func (InboxChannel) Name() string {
+	return "inbox"
+}
+
+func (i InboxChannel) Send(message Message) error {
+	notification := model.Notification{
+		UserID:  message.UserID,
+		Kind:    message.Kind,
+		Subject: message.Subject,
+		Body:    message.Body,
+	}
+	if message.Data != nil {
+		data, err := json.Marshal(message.Data)
+		if err != nil {
+			return err
+		}
+		notification.Data = string(data)
+	}
+	return i.DB.Create(&notification).Error
+}
+
+// DBPreferences membaca preferensi dari tabel notification_preferences. Preferensi untuk
+// jenis tertentu didahulukan dari preferensi "*"; tanpa keduanya channel dianggap aktif.
+type DBPreferences struct {
+	DB *gorm.DB
+}
+
+func (p DBPreferences) Enabled(userID uint, kind, channel string) bool {
+	var preferences []model.NotificationPreference
+	if err := p.DB.Where("user_id = ? AND channel = ? AND kind IN ?", userID, channel, []string{kind, "*"}).Find(&preferences).Error; err != nil {
+		return true
+	}
+	enabled := true
+	for _, preference := range preferences {
+		if preference.Kind == kind {
+			return preference.Enabled
+		}
+		enabled = preference.Enabled
+	}
+	return enabled
+}
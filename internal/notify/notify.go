@@ -0,0 +1,116 @@
+// Package notify mengirim pemberitahuan ke user lewat satu atau beberapa channel
+// (log, email, webhook, ...). Channel baru cukup mengimplementasikan interface Channel
+// lalu didaftarkan ke Dispatcher.
+package notify
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// Jenis pemberitahuan yang dikirim aplikasi
+const (
+	KindBillReminder       = "bill_reminder"
+	KindBillOverdue        = "bill_overdue"
+	KindUnusualTransaction = "unusual_transaction"
+	KindSpendingSpike      = "spending_spike"
+	KindBudgetThreshold    = "budget_threshold"
+)
+
+// Kinds adalah semua jenis pemberitahuan, dipakai untuk pengaturan preferensi
+var Kinds = []string{KindBillReminder, KindBillOverdue, KindUnusualTransaction, KindSpendingSpike, KindBudgetThreshold}
+
+// Message adalah satu pemberitahuan untuk satu user
+type Message struct {
+	UserID  uint                   `json:"user_id"`
+	Kind    string                 `json:"kind"` // salah satu Kinds
+	Subject string                 `json:"subject"`
+	Body    string                 `json:"body"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// Channel adalah tujuan pengiriman pemberitahuan
+type Channel interface {
+	Name() string
+	Send(message Message) error
+}
+
+// LogChannel menulis pemberitahuan ke log aplikasi, berguna untuk development
+type LogChannel struct{}
+
+func (LogChannel) Name() string {
+	return "log"
+}
+
+func (LogChannel) Send(message Message) error {
+	log.Printf("[notify] user=%d kind=%s subject=%q body=%q", message.UserID, message.Kind, message.Subject, message.Body)
+	return nil
+}
+
+// Preferences menentukan apakah user mau menerima jenis pemberitahuan lewat sebuah channel
+type Preferences interface {
+	Enabled(userID uint, kind, channel string) bool
+}
+
+// Dispatcher meneruskan setiap pemberitahuan ke semua channel yang terdaftar dan
+// diizinkan oleh preferensi user
+type Dispatcher struct {
+	mu          sync.RWMutex
+	channels    []Channel
+	preferences Preferences
+}
+
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	return &Dispatcher{channels: channels}
+}
+
+// Register menambahkan channel baru
+func (d *Dispatcher) Register(channel Channel) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.channels = append(d.channels, channel)
+}
+
+// SetPreferences memasang preferensi user, tanpa preferensi semua channel dipakai
+func (d *Dispatcher) SetPreferences(preferences Preferences) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.preferences = preferences
+}
+
+// Channels mengembalikan nama semua channel yang terdaftar
+func (d *Dispatcher) Channels() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	names := make([]string, 0, len(d.channels))
+	for _, channel := range d.channels {
+		names = append(names, channel.Name())
+	}
+	return names
+}
+
+// Send mengirim ke semua channel. Kegagalan satu channel tidak menghentikan channel
+// lain; delivered adalah jumlah channel yang berhasil dan semua error digabung.
+func (d *Dispatcher) Send(message Message) (delivered int, err error) {
+	d.mu.RLock()
+	channels := append([]Channel{}, d.channels...)
+	preferences := d.preferences
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, channel := range channels {
+		if preferences != nil && !preferences.Enabled(message.UserID, message.Kind, channel.Name()) {
+			continue
+		}
+		if err := channel.Send(message); err != nil {
+			errs = append(errs, errors.New(channel.Name()+": "+err.Error()))
+			continue
+		}
+		delivered++
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// Default adalah dispatcher yang dipakai aplikasi
+var Default = NewDispatcher(LogChannel{})
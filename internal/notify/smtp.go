@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/TheRaccoon-Black/goMoneyApi/internal/model"
+	"gorm.io/gorm"
+)
+
+// errNoRecipient dikembalikan jika user tidak punya alamat email
+var errNoRecipient = errors.New("recipient email not found")
+
+// SMTPChannel mengirim pemberitahuan sebagai email ke alamat email user
+type SMTPChannel struct {
+	DB       *gorm.DB
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func NewSMTPChannel(db *gorm.DB, host, port, username, password, from string) *SMTPChannel {
+	if port == "" {
+		port = "587"
+	}
+	if from == "" {
+		from = username
+	}
+	return &SMTPChannel{DB: db, Host: host, Port: port, Username: username, Password: password, From: from}
+}
+
+func (s *SMTPChannel) Name() string {
+	return "email"
+}
+
+func (s *SMTPChannel) Send(message Message) error {
+	var user model.User
+	if err := s.DB.Select("id", "email").First(&user, message.UserID).Error; err != nil || user.Email == "" {
+		return errNoRecipient
+	}
+
+	// Baris baru di subjek bisa menyisipkan header lain
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(message.Subject)
+	body := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		s.From, user.Email, subject, message.Body)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, []string{user.Email}, []byte(body))
+}
@@ -0,0 +1,137 @@
+// Package realtime menyebarkan event perubahan data ke semua koneksi stream milik user
+// (mis. aplikasi yang terbuka di ponsel dan laptop). Broker menyimpan sejumlah event
+// terakhir per user agar klien yang tersambung ulang bisa melanjutkan dari Last-Event-ID.
+//
+// Broker hanya hidup di memori satu proses; jika API dijalankan di beberapa instance,
+// event hanya sampai ke klien yang tersambung ke instance yang sama.
+package realtime
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Ukuran buffer per koneksi. Koneksi yang tertinggal lebih dari ini diputus agar
+// tersambung ulang dan mengejar lewat riwayat, bukan menahan pengiriman ke yang lain.
+const subscriberBuffer = 32
+
+// Event adalah satu pesan stream. Data sudah dalam bentuk JSON.
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte
+}
+
+// Subscription adalah satu koneksi stream. C ditutup jika koneksi tertinggal atau dihentikan.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	userID uint
+	broker *Broker
+	closed bool
+}
+
+// Close melepas koneksi dari broker
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+type userStream struct {
+	history     []Event
+	evicted     uint64 // ID terbesar yang sudah dibuang dari riwayat
+	subscribers map[*Subscription]struct{}
+}
+
+type Broker struct {
+	mu          sync.Mutex
+	historySize int
+	startID     uint64
+	lastID      uint64
+	users       map[uint]*userStream
+}
+
+// Default adalah broker yang dipakai aplikasi
+var Default = NewBroker(100)
+
+// NewBroker membuat broker yang menyimpan historySize event terakhir per user. ID event
+// dimulai dari waktu start (milidetik * 1000) sehingga tetap naik setelah server restart.
+func NewBroker(historySize int) *Broker {
+	startID := uint64(time.Now().UnixMilli()) * 1000
+	return &Broker{historySize: historySize, startID: startID, lastID: startID, users: make(map[uint]*userStream)}
+}
+
+func (b *Broker) stream(userID uint) *userStream {
+	stream, ok := b.users[userID]
+	if !ok {
+		stream = &userStream{subscribers: make(map[*Subscription]struct{})}
+		b.users[userID] = stream
+	}
+	return stream
+}
+
+func (b *Broker) remove(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	close(s.ch)
+	if stream, ok := b.users[s.userID]; ok {
+		delete(stream.subscribers, s)
+	}
+}
+
+// Publish menyimpan event ke riwayat user dan mengirimkannya ke semua koneksi user tersebut
+func (b *Broker) Publish(userID uint, eventType string, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	event := Event{ID: b.lastID, Type: eventType, Data: payload}
+
+	stream := b.stream(userID)
+	stream.history = append(stream.history, event)
+	if len(stream.history) > b.historySize {
+		stream.evicted = stream.history[0].ID
+		stream.history = stream.history[1:]
+	}
+	for subscription := range stream.subscribers {
+		select {
+		case subscription.ch <- event:
+		default:
+			b.remove(subscription)
+		}
+	}
+	return event, nil
+}
+
+// Subscribe membuka koneksi baru untuk user. Event dengan ID lebih besar dari lastEventID
+// yang masih ada di riwayat dikembalikan sebagai backlog. complete bernilai false jika
+// sebagian event setelah lastEventID sudah tidak tersedia (riwayat terpotong atau server
+// restart), sehingga klien perlu memuat ulang data secara penuh.
+func (b *Broker) Subscribe(userID uint, lastEventID uint64) (subscription *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stream := b.stream(userID)
+	ch := make(chan Event, subscriberBuffer)
+	subscription = &Subscription{C: ch, ch: ch, userID: userID, broker: b}
+	stream.subscribers[subscription] = struct{}{}
+
+	complete = true
+	if lastEventID > 0 {
+		complete = lastEventID >= b.startID && lastEventID >= stream.evicted && lastEventID <= b.lastID
+		for _, event := range stream.history {
+			if event.ID > lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+	return subscription, backlog, complete
+}
@@ -0,0 +1,88 @@
+package realtime
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Tickets menyimpan tiket stream berumur pendek. EventSource di browser tidak bisa mengirim header
+// Authorization, jadi klien menukar JWT dengan tiket lalu mengirimnya lewat ?ticket=.
+//
+// Tiket adalah sesi stream: EventSource yang terputus tersambung ulang ke URL yang sama (dengan
+// header Last-Event-ID), jadi tiket tetap bisa dibuka lagi selama stream-nya tersambung dan
+// sampai ttl setelah koneksi terakhirnya putus. Setelah itu tiket hangus dan klien harus
+// meminta tiket baru lalu tersambung dengan ?last_event_id=.
+type Tickets struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	tickets map[string]*ticket
+}
+
+type ticket struct {
+	userID    uint
+	open      int // jumlah stream yang sedang memakai tiket ini
+	expiresAt time.Time
+}
+
+// DefaultTickets adalah penyimpanan tiket yang dipakai aplikasi
+var DefaultTickets = NewTickets(time.Minute)
+
+// NewTickets membuat penyimpanan tiket yang berlaku selama ttl sejak dibuat atau sejak
+// stream terakhir yang memakainya ditutup
+func NewTickets(ttl time.Duration) *Tickets {
+	return &Tickets{ttl: ttl, tickets: make(map[string]*ticket)}
+}
+
+// Issue membuat tiket baru untuk user beserta waktu kedaluwarsanya
+func (t *Tickets) Issue(userID uint) (string, time.Time, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, err
+	}
+	value := hex.EncodeToString(raw)
+	expiresAt := time.Now().Add(t.ttl)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.purge(time.Now())
+	t.tickets[value] = &ticket{userID: userID, expiresAt: expiresAt}
+	return value, expiresAt, nil
+}
+
+// Open membuka stream dengan tiket dan mengembalikan pemiliknya. close wajib dipanggil saat
+// stream selesai; sejak itu tiket masih bisa dibuka lagi selama ttl. Tiket yang tidak dikenal
+// atau sudah kedaluwarsa ditolak.
+func (t *Tickets) Open(value string) (userID uint, close func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	issued, found := t.tickets[value]
+	if !found {
+		return 0, nil, false
+	}
+	if issued.open == 0 && time.Now().After(issued.expiresAt) {
+		delete(t.tickets, value)
+		return 0, nil, false
+	}
+	issued.open++
+
+	var once sync.Once
+	return issued.userID, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			issued.open--
+			issued.expiresAt = time.Now().Add(t.ttl)
+		})
+	}, true
+}
+
+// purge membuang tiket kedaluwarsa yang tidak sedang dipakai stream
+func (t *Tickets) purge(now time.Time) {
+	for value, issued := range t.tickets {
+		if issued.open == 0 && now.After(issued.expiresAt) {
+			delete(t.tickets, value)
+		}
+	}
+}
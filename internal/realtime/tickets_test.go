@@ -0,0 +1,43 @@
+package realtime
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTicketReopensUntilTTLAfterClose(t *testing.T) {
+	ttl := 30 * time.Millisecond
+	tickets := NewTickets(ttl)
+	value, _, err := tickets.Issue(7)
+	if err != nil {
+		t.Fatalf("failed to issue ticket: %v", err)
+	}
+
+	userID, closeFirst, ok := tickets.Open(value)
+	if !ok || userID != 7 {
+		t.Fatalf("expected ticket to open for user 7, got %d %v", userID, ok)
+	}
+	// Stream yang masih tersambung membuat tiket tetap berlaku melewati ttl
+	time.Sleep(2 * ttl)
+	_, closeSecond, ok := tickets.Open(value)
+	if !ok {
+		t.Fatal("expected ticket to reopen while a stream is still open")
+	}
+	closeFirst()
+	closeSecond()
+
+	// Reconnect segera setelah putus masih diterima
+	_, closeThird, ok := tickets.Open(value)
+	if !ok {
+		t.Fatal("expected ticket to reopen right after disconnect")
+	}
+	closeThird()
+
+	time.Sleep(2 * ttl)
+	if _, _, ok := tickets.Open(value); ok {
+		t.Fatal("expected ticket to expire ttl after the last stream closed")
+	}
+	if _, _, ok := tickets.Open("unknown"); ok {
+		t.Fatal("expected unknown ticket to be rejected")
+	}
+}
@@ -0,0 +1,155 @@
+// Package recurring mendeteksi pola tagihan berulang (langganan) dari riwayat transaksi:
+// nominal yang mirip dengan jarak waktu yang teratur.
+package recurring
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	FrequencyWeekly    = "weekly"
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyYearly    = "yearly"
+)
+
+// Toleransi selisih nominal terhadap median (20%)
+const AmountTolerance = 0.2
+
+type frequency struct {
+	Name       string
+	MinDays    int
+	MaxDays    int
+	PerYear    float64
+	MinCharges int
+}
+
+// Rentang jarak hari yang dianggap satu frekuensi, beserta jumlah transaksi minimum
+var frequencies = []frequency{
+	{FrequencyWeekly, 6, 8, 52, 4},
+	{FrequencyMonthly, 27, 33, 12, 3},
+	{FrequencyQuarterly, 85, 97, 4, 3},
+	{FrequencyYearly, 355, 375, 1, 2},
+}
+
+// Charge adalah satu transaksi dalam satu kelompok (payee/catatan yang sama)
+type Charge struct {
+	Date   time.Time
+	Amount float64
+}
+
+// Pattern adalah langganan yang terdeteksi dari sekelompok transaksi
+type Pattern struct {
+	Frequency        string
+	Occurrences      int
+	AverageAmount    float64
+	AnnualCost       float64
+	LastChargeDate   time.Time
+	NextExpectedDate time.Time
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// addMonths menambahkan bulan tanpa melompat ke bulan berikutnya (31 Jan + 1 bulan = 28/29 Feb)
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// NextDate menambahkan satu periode frekuensi ke tanggal
+func NextDate(date time.Time, name string) time.Time {
+	switch name {
+	case FrequencyWeekly:
+		return date.AddDate(0, 0, 7)
+	case FrequencyQuarterly:
+		return addMonths(date, 3)
+	case FrequencyYearly:
+		return addMonths(date, 12)
+	default:
+		return addMonths(date, 1)
+	}
+}
+
+// Detect memeriksa apakah transaksi-transaksi membentuk pola berulang. Semua jarak antar
+// transaksi harus masuk rentang satu frekuensi dan semua nominal dalam toleransi median.
+// Beberapa transaksi di hari yang sama dihitung sebagai satu tagihan.
+func Detect(charges []Charge) (Pattern, bool) {
+	sorted := append([]Charge{}, charges...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	merged := []Charge{}
+	for _, charge := range sorted {
+		day := time.Date(charge.Date.Year(), charge.Date.Month(), charge.Date.Day(), 0, 0, 0, 0, time.UTC)
+		if len(merged) > 0 && merged[len(merged)-1].Date.Equal(day) {
+			merged[len(merged)-1].Amount += charge.Amount
+			continue
+		}
+		merged = append(merged, Charge{Date: day, Amount: charge.Amount})
+	}
+	if len(merged) < 2 {
+		return Pattern{}, false
+	}
+
+	amounts := make([]float64, len(merged))
+	for i, charge := range merged {
+		amounts[i] = charge.Amount
+	}
+	typical := median(amounts)
+	if typical <= 0 {
+		return Pattern{}, false
+	}
+	total := 0.0
+	for _, amount := range amounts {
+		if math.Abs(amount-typical) > typical*AmountTolerance {
+			return Pattern{}, false
+		}
+		total += amount
+	}
+
+	for _, freq := range frequencies {
+		if len(merged) < freq.MinCharges {
+			continue
+		}
+		regular := true
+		for i := 1; i < len(merged); i++ {
+			days := int(merged[i].Date.Sub(merged[i-1].Date).Hours() / 24)
+			if days < freq.MinDays || days > freq.MaxDays {
+				regular = false
+				break
+			}
+		}
+		if !regular {
+			continue
+		}
+		average := round(total / float64(len(merged)))
+		last := merged[len(merged)-1].Date
+		return Pattern{
+			Frequency:        freq.Name,
+			Occurrences:      len(merged),
+			AverageAmount:    average,
+			AnnualCost:       round(average * freq.PerYear),
+			LastChargeDate:   last,
+			NextExpectedDate: NextDate(last, freq.Name),
+		}, true
+	}
+	return Pattern{}, false
+}
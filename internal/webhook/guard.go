@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrAddressNotAllowed dikembalikan jika URL webhook mengarah ke jaringan internal
+var ErrAddressNotAllowed = errors.New("webhook address is not allowed")
+
+// Rentang yang tidak tercakup oleh method net.IP di bawah: "this network" dan CGNAT
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// AllowedIP menolak alamat loopback, privat, link-local, multicast dan unspecified agar
+// webhook tidak bisa dipakai untuk menjangkau jaringan internal server
+func AllowedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateURL memastikan URL http(s) dan semua alamat host-nya boleh dihubungi. Pemeriksaan
+// ini diulang saat dial (lihat NewSender) karena DNS bisa berubah setelah validasi.
+func ValidateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return errors.New("url must be an http or https URL")
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !AllowedIP(ip) {
+			return ErrAddressNotAllowed
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errors.New("url host could not be resolved")
+	}
+	for _, addr := range addrs {
+		if !AllowedIP(addr.IP) {
+			return ErrAddressNotAllowed
+		}
+	}
+	return nil
+}
+
+// safeDialControl memeriksa alamat yang benar-benar akan dihubungi setelah DNS di-resolve,
+// sehingga DNS rebinding dan redirect ke alamat internal tetap tertolak
+func safeDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !AllowedIP(ip) {
+		return ErrAddressNotAllowed
+	}
+	return nil
+}
+
+// newSafeClient membuat HTTP client yang hanya bisa terhubung ke alamat publik. Proxy dari
+// environment tidak dipakai agar pemeriksaan dial berlaku pada alamat tujuan sebenarnya.
+func newSafeClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: safeDialControl}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConnsPerHost:   2,
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
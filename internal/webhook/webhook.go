@@ -0,0 +1,167 @@
+// Package webhook mengirim event perubahan data ke endpoint milik user. Setiap payload
+// ditandatangani dengan HMAC-SHA256 memakai secret endpoint dan dikirim ulang dengan
+// jeda eksponensial jika gagal.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Jenis event yang bisa dilanggan
+const (
+	EventTransactionCreated = "transaction.created"
+	EventTransactionUpdated = "transaction.updated"
+	EventTransactionDeleted = "transaction.deleted"
+	EventAccountCreated     = "account.created"
+	EventAccountUpdated     = "account.updated"
+	EventAccountDeleted     = "account.deleted"
+	EventBudgetUpdated      = "budget.updated"
+	EventBudgetExceeded     = "budget.exceeded"
+	// Pemberitahuan (pengingat tagihan, anomali, ambang budget) yang dikirim lewat channel webhook
+	EventNotification = "notification"
+	// Dikirim oleh endpoint ping, tidak perlu dilanggan
+	EventPing = "ping"
+)
+
+// Events adalah semua event yang bisa dilanggan
+var Events = []string{
+	EventTransactionCreated, EventTransactionUpdated, EventTransactionDeleted,
+	EventAccountCreated, EventAccountUpdated, EventAccountDeleted,
+	EventBudgetUpdated, EventBudgetExceeded,
+	EventNotification,
+}
+
+// Header yang dikirim bersama payload. Signature = hex(HMAC-SHA256(secret, timestamp + "." + body)).
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-Id"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Batas isi response yang disimpan di log pengiriman
+const maxResponseBody = 2048
+
+// Event adalah payload yang dikirim ke endpoint
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// Attempt adalah hasil satu kali percobaan pengiriman
+type Attempt struct {
+	Number       int
+	StatusCode   int
+	ResponseBody string
+	Err          error
+	Duration     time.Duration
+}
+
+func (a Attempt) Success() bool {
+	return a.Err == nil && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// NewSecret membuat secret acak untuk endpoint baru
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// NewEvent membuat event dengan ID acak
+func NewEvent(eventType string, data interface{}) Event {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return Event{ID: hex.EncodeToString(buf), Type: eventType, CreatedAt: time.Now().UTC(), Data: data}
+}
+
+// Sign menghitung tanda tangan HMAC-SHA256 untuk payload
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify memeriksa tanda tangan, bisa dipakai penerima webhook
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Sender mengirim event dengan percobaan ulang. Jeda sebelum percobaan ke-n adalah
+// BaseDelay * 2^(n-2), jadi 1 detik, 2 detik, 4 detik, ... untuk BaseDelay 1 detik.
+type Sender struct {
+	Client      *http.Client
+	MaxAttempts int
+	BaseDelay   time.Duration
+}
+
+// NewSender membuat pengirim yang hanya terhubung ke alamat publik (lihat AllowedIP)
+func NewSender() *Sender {
+	return &Sender{Client: newSafeClient(10 * time.Second), MaxAttempts: 5, BaseDelay: time.Second}
+}
+
+// Send melakukan satu percobaan pengiriman
+func (s *Sender) Send(url, secret string, event Event, number int) Attempt {
+	attempt := Attempt{Number: number}
+	body, err := json.Marshal(event)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(HeaderEvent, event.Type)
+	request.Header.Set(HeaderID, event.ID)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	started := time.Now()
+	response, err := s.Client.Do(request)
+	attempt.Duration = time.Since(started)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	defer response.Body.Close()
+	responseBody, _ := io.ReadAll(io.LimitReader(response.Body, maxResponseBody))
+	attempt.StatusCode = response.StatusCode
+	attempt.ResponseBody = string(responseBody)
+	return attempt
+}
+
+// Deliver mengirim event sampai berhasil atau MaxAttempts habis. record dipanggil setelah
+// setiap percobaan, misalnya untuk menyimpan log pengiriman.
+func (s *Sender) Deliver(url, secret string, event Event, record func(Attempt)) bool {
+	for number := 1; number <= s.MaxAttempts; number++ {
+		if number > 1 {
+			time.Sleep(s.BaseDelay * time.Duration(1<<(number-2)))
+		}
+		attempt := s.Send(url, secret, event, number)
+		if record != nil {
+			record(attempt)
+		}
+		if attempt.Success() {
+			return true
+		}
+	}
+	return false
+}
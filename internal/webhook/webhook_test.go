@@ -0,0 +1,192 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver adalah penerima webhook lokal yang mencatat setiap request
+type receiver struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	times    []time.Time
+	statuses []int // status per request, request berikutnya memakai status terakhir
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request)
+	r.bodies = append(r.bodies, body)
+	r.times = append(r.times, time.Now())
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status = r.statuses[min(len(r.requests), len(r.statuses))-1]
+	}
+	w.WriteHeader(status)
+}
+
+func newTestSender(server *httptest.Server, maxAttempts int, baseDelay time.Duration) *Sender {
+	// Client bawaan httptest dipakai karena NewSender menolak alamat loopback
+	return &Sender{Client: server.Client(), MaxAttempts: maxAttempts, BaseDelay: baseDelay}
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	event := NewEvent(EventTransactionCreated, map[string]int{"id": 7})
+	attempt := newTestSender(server, 1, 0).Send(server.URL, "top-secret", event, 1)
+	if !attempt.Success() {
+		t.Fatalf("expected success, got status %d err %v", attempt.StatusCode, attempt.Err)
+	}
+	if len(recv.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(recv.requests))
+	}
+
+	request, body := recv.requests[0], recv.bodies[0]
+	timestamp, err := strconv.ParseInt(request.Header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	signature := request.Header.Get(HeaderSignature)
+	if !Verify("top-secret", timestamp, body, signature) {
+		t.Fatalf("signature %q does not verify", signature)
+	}
+	if Verify("other-secret", timestamp, body, signature) {
+		t.Fatal("signature verified with the wrong secret")
+	}
+	if Verify("top-secret", timestamp, append(body, ' '), signature) {
+		t.Fatal("signature verified for a modified body")
+	}
+	if got := request.Header.Get(HeaderEvent); got != EventTransactionCreated {
+		t.Fatalf("expected event header %q, got %q", EventTransactionCreated, got)
+	}
+	if got := request.Header.Get(HeaderID); got != event.ID {
+		t.Fatalf("expected id header %q, got %q", event.ID, got)
+	}
+
+	var received Event
+	if err := json.Unmarshal(body, &received); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if received.ID != event.ID || received.Type != event.Type {
+		t.Fatalf("unexpected payload %+v", received)
+	}
+}
+
+func TestDeliverRetriesWithBackoff(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	baseDelay := 20 * time.Millisecond
+	var attempts []Attempt
+	ok := newTestSender(server, 5, baseDelay).Deliver(server.URL, "secret", NewEvent(EventPing, nil), func(attempt Attempt) {
+		attempts = append(attempts, attempt)
+	})
+	if !ok {
+		t.Fatal("expected delivery to succeed")
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(attempts))
+	}
+	for i, want := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK} {
+		if attempts[i].Number != i+1 || attempts[i].StatusCode != want {
+			t.Fatalf("attempt %d: got number %d status %d", i, attempts[i].Number, attempts[i].StatusCode)
+		}
+	}
+	// Jeda sebelum percobaan ke-2 adalah BaseDelay, sebelum ke-3 dua kali lipatnya
+	for i, want := range []time.Duration{baseDelay, 2 * baseDelay} {
+		if gap := recv.times[i+1].Sub(recv.times[i]); gap < want {
+			t.Fatalf("gap before attempt %d was %v, expected at least %v", i+2, gap, want)
+		}
+	}
+}
+
+func TestDeliverGivesUpAfterMaxAttempts(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	recorded := 0
+	ok := newTestSender(server, 3, time.Millisecond).Deliver(server.URL, "secret", NewEvent(EventPing, nil), func(attempt Attempt) {
+		recorded++
+		if attempt.Success() {
+			t.Fatalf("attempt %d unexpectedly succeeded", attempt.Number)
+		}
+	})
+	if ok {
+		t.Fatal("expected delivery to fail")
+	}
+	if recorded != 3 || len(recv.requests) != 3 {
+		t.Fatalf("expected 3 recorded attempts and requests, got %d and %d", recorded, len(recv.requests))
+	}
+}
+
+func TestNewSenderRefusesInternalAddresses(t *testing.T) {
+	recv := &receiver{}
+	server := httptest.NewServer(recv)
+	defer server.Close()
+
+	attempt := NewSender().Send(server.URL, "secret", NewEvent(EventPing, nil), 1)
+	if !errors.Is(attempt.Err, ErrAddressNotAllowed) {
+		t.Fatalf("expected ErrAddressNotAllowed, got %v", attempt.Err)
+	}
+	if len(recv.requests) != 0 {
+		t.Fatal("request reached the loopback receiver")
+	}
+}
+
+func TestAllowedIP(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"0.1.2.3":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"::ffff:127.0.0.1": false,
+	}
+	for address, want := range cases {
+		if got := AllowedIP(net.ParseIP(address)); got != want {
+			t.Errorf("AllowedIP(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	cases := map[string]bool{
+		"https://93.184.216.34/hook":               true,
+		"http://127.0.0.1:3306/":                   false,
+		"http://169.254.169.254/latest/meta-data/": false,
+		"http://[::1]/":                            false,
+		"http://10.0.0.5/hook":                     false,
+		"http://localhost/hook":                    false,
+		"ftp://93.184.216.34/":                     false,
+		"not a url":                                false,
+	}
+	for raw, want := range cases {
+		if err := ValidateURL(raw); (err == nil) != want {
+			t.Errorf("ValidateURL(%q) = %v, want allowed=%v", raw, err, want)
+		}
+	}
+}